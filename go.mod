@@ -3,17 +3,19 @@ module github.com/vinyldns/vinyldns-cli
 go 1.21
 
 require (
-	github.com/crackcomm/go-clitable v0.0.0-20151121230230-53bcff2fea36
+	github.com/aws/aws-sdk-go-v2 v1.26.1
 	github.com/olekukonko/tablewriter v0.0.4
 	github.com/urfave/cli v1.22.17
 	github.com/vinyldns/go-vinyldns v0.9.17
+	golang.org/x/net v0.23.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/aws/aws-sdk-go-v2 v1.26.1 // indirect
 	github.com/aws/aws-sdk-go-v2/credentials v1.17.11 // indirect
 	github.com/aws/smithy-go v1.20.2 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.7 // indirect
 	github.com/mattn/go-runewidth v0.0.7 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
 )
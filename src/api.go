@@ -0,0 +1,252 @@
+/*
+Copyright 2018 Comcast Cable Communications Management, LLC
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// apiMethods are the HTTP methods the api command sends.
+var apiMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+// api sends a signed request to any VinylDNS API endpoint, including ones
+// the other commands do not cover, and prints the response.
+func api(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return usageError(fmt.Errorf("api takes a method and a path, e.g. 'api GET /zones'; got %d arguments", c.NArg()))
+	}
+
+	method := ""
+	for _, m := range apiMethods {
+		if strings.EqualFold(m, c.Args().Get(0)) {
+			method = m
+		}
+	}
+	if method == "" {
+		return usageError(fmt.Errorf("unknown method %s; must be one of: %s", c.Args().Get(0), strings.Join(apiMethods, ", ")))
+	}
+
+	if c.GlobalBool(wideFlag) {
+		return usageError(fmt.Errorf("--%s does not apply to api, whose lists show every field; pick them with --%s instead", wideFlag, columnsFlag))
+	}
+
+	path := c.Args().Get(1)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	var body []byte
+	if d := c.String("data"); d != "" {
+		data, err := readJSON(d)
+		if err != nil {
+			return err
+		}
+		if !json.Valid(data) {
+			return invalidError(errors.New("--data is not valid JSON"))
+		}
+		body = data
+	}
+
+	contents, err := rawRequest(client(c), method, path, body)
+	if err != nil {
+		return err
+	}
+
+	return printAPIResponse(c, contents, fmt.Sprintf("%s %s succeeded", method, path))
+}
+
+// printAPIResponse prints a JSON response body through the output format.
+// Tables show an object one field per row, unwrapping responses such as
+// {"zone": {...}}, and a list, or a page of one such as {"zones": [...],
+// "maxItems": 100}, one item per row, sorted by --sort-by. Bodies that are
+// not JSON are printed as text, with their secrets masked.
+func printAPIResponse(c *cli.Context, contents []byte, message string) error {
+	if len(bytes.TrimSpace(contents)) == 0 {
+		return printResult(c, nil, message)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(contents))
+	dec.UseNumber()
+	var resp interface{}
+	if err := dec.Decode(&resp); err != nil {
+		text := string(contents)
+		if !c.GlobalBool(showSecretsFlag) {
+			text = redactText(text)
+		}
+		if ok, err := printStructured(c, text); ok {
+			return err
+		}
+		fmt.Println(text)
+		return nil
+	}
+
+	if items, ok := apiList(resp); ok {
+		if err := sortAPIItems(c, items); err != nil {
+			return err
+		}
+	}
+	if ok, err := printStructured(c, resp); ok {
+		return err
+	}
+
+	resp = redact(c, resp)
+	if items, ok := apiList(resp); ok {
+		return printAPIList(c, items)
+	}
+
+	obj, ok := resp.(map[string]interface{})
+	if len(obj) == 1 {
+		for _, v := range obj {
+			if inner, isObj := v.(map[string]interface{}); isObj {
+				obj = inner
+			}
+		}
+	}
+	if !ok {
+		fmt.Println(formatValue(reflect.ValueOf(resp)))
+		return nil
+	}
+
+	rows := [][]string{}
+	for _, k := range sortedKeys(obj) {
+		rows = append(rows, []string{k, formatValue(reflect.ValueOf(obj[k]))})
+	}
+	if outputFormat(c) == tableOutput {
+		printBasicTable(rows)
+		return nil
+	}
+
+	return printRows(c, []string{"Field", "Value"}, rows)
+}
+
+// apiList returns the list in resp if it is a list of objects, or a page of
+// one. The pages of the VinylDNS API have maxItems and exactly one list; an
+// object with nothing but a list counts too.
+func apiList(resp interface{}) ([]interface{}, bool) {
+	if obj, ok := resp.(map[string]interface{}); ok {
+		if _, ok := obj["maxItems"]; !ok && len(obj) != 1 {
+			return nil, false
+		}
+		var list interface{}
+		for _, v := range obj {
+			if _, ok := v.([]interface{}); ok {
+				if list != nil {
+					return nil, false
+				}
+				list = v
+			}
+		}
+		resp = list
+	}
+
+	l, ok := resp.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	for _, v := range l {
+		if _, ok := v.(map[string]interface{}); !ok {
+			return nil, false
+		}
+	}
+
+	return l, true
+}
+
+// sortAPIItems sorts the objects in items in place by their --sort-by field.
+// A leading "-" sorts in descending order.
+func sortAPIItems(c *cli.Context, items []interface{}) error {
+	key := c.GlobalString(sortByFlag)
+	if key == "" || len(items) == 0 {
+		return nil
+	}
+
+	desc := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+	all := apiFields(items)
+	if _, ok := all[key]; !ok {
+		return usageError(fmt.Errorf("unknown --%s field %s; available fields: %s", sortByFlag, key, strings.Join(sortedKeys(all), ", ")))
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a := apiValue(items[i].(map[string]interface{})[key])
+		b := apiValue(items[j].(map[string]interface{})[key])
+		if desc {
+			return compareValues(b, a) < 0
+		}
+		return compareValues(a, b) < 0
+	})
+
+	return nil
+}
+
+// apiFields returns the fields of the objects in items, with a value of each.
+func apiFields(items []interface{}) map[string]interface{} {
+	all := map[string]interface{}{}
+	for _, item := range items {
+		for k, v := range item.(map[string]interface{}) {
+			all[k] = v
+		}
+	}
+
+	return all
+}
+
+// apiValue returns v for compareValues, with integers as int64 rather than
+// json.Number strings so that they sort numerically.
+func apiValue(v interface{}) reflect.Value {
+	if n, ok := v.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return reflect.ValueOf(i)
+		}
+	}
+
+	return reflect.ValueOf(v)
+}
+
+// printAPIList prints items one per row, with a column for each of their
+// fields, or the fields --columns picks.
+func printAPIList(c *cli.Context, items []interface{}) error {
+	columns := []string{}
+	if cols := c.GlobalString(columnsFlag); cols != "" {
+		for _, col := range strings.Split(cols, ",") {
+			columns = append(columns, strings.TrimSpace(col))
+		}
+	} else {
+		columns = sortedKeys(apiFields(items))
+	}
+
+	if len(items) == 0 && outputFormat(c) == tableOutput {
+		fmt.Println("No items found")
+		return nil
+	}
+
+	rows := [][]string{}
+	for _, item := range items {
+		row := []string{}
+		for _, col := range columns {
+			row = append(row, formatValue(reflect.ValueOf(item.(map[string]interface{})[col])))
+		}
+		rows = append(rows, row)
+	}
+
+	return printRows(c, columns, rows)
+}
@@ -17,8 +17,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-
-	clitable "github.com/crackcomm/go-clitable"
+	"time"
 
 	"github.com/urfave/cli"
 
@@ -27,32 +26,24 @@ import (
 
 func batchChanges(c *cli.Context) error {
 	client := client(c)
-	rc, err := client.BatchRecordChanges()
+	rc, err := listBatchChanges(client, c.String("approval-status"), c.Bool("ignore-access"))
 	if err != nil {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(rc)
-	}
-
-	changes := []map[string]interface{}{}
-	for _, r := range rc {
-		m := map[string]interface{}{}
-		m["ID"] = r.ID
-		m["CreatedTimestamp"] = r.CreatedTimestamp
-		m["Comments"] = r.Comments
-
-		changes = append(changes, m)
+	if c.Bool("scheduled") {
+		return printList(c, scheduledBatchChanges(rc, time.Now()), []field{
+			{"ScheduledTime", "LocalTime"},
+			{"ID", "ID"},
+			{"Status", "Status"},
+			{"ApprovalStatus", "ApprovalStatus"},
+			{"UserName", "UserName"},
+			{"Comments", "Comments"},
+		}, nil, "scheduled batch changes")
 	}
 
-	if len(changes) != 0 {
-		clitable.PrintTable([]string{"ID", "CreatedTimestamp", "Comments"}, changes)
-	} else {
-		fmt.Println("No batch changes found")
-	}
-
-	return nil
+	return printList(c, rc, fields("ID", "CreatedTimestamp", "Comments"),
+		fields("ID", "UserName", "Status", "ApprovalStatus", "TotalChanges", "OwnerGroupID", "CreatedTimestamp", "Comments"), "batch changes")
 }
 
 func batchChange(c *cli.Context) error {
@@ -62,36 +53,55 @@ func batchChange(c *cli.Context) error {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(rc)
+	// the changes are listed in table, csv and tsv output, but the whole
+	// batch change is printed as JSON or YAML
+	if ok, err := printStructured(c, rc); ok {
+		return err
 	}
 
-	change := [][]string{}
-	for _, r := range rc.Changes {
-		changeElem := []string{}
-
-		changeElem = append(changeElem, `"ChangeType" - `+r.ChangeType)
-		changeElem = append(changeElem, `"InputName" - `+r.InputName)
-		changeElem = append(changeElem, `"Type" - `+r.Type)
-		changeElem = append(changeElem, `"TTL" - `+strconv.Itoa(r.TTL))
-
-		recordData, err := json.Marshal(&(r.Record))
+	// csv and tsv use the column layout batch-change-create --csv reads
+	if f := outputFormat(c); f == csvOutput || f == tsvOutput {
+		rows, err := batchChangeRows(client, rc.ID)
 		if err != nil {
 			return err
 		}
-		changeElem = append(changeElem, `"Record" - `+string(recordData))
-		changeElem = append(changeElem, `"Status" - `+r.Status)
+		return printList(c, rows, batchChangeRowFields, nil, "changes")
+	}
 
-		change = append(change, changeElem)
+	return printList(c, rc.Changes, fields("ChangeType", "InputName", "Type", "TTL", "Record", "Status"),
+		fields("ChangeType", "InputName", "Type", "TTL", "Record", "Status", "ZoneName", "RecordName", "ID"), "changes")
+}
+
+func batchChangeApprove(c *cli.Context) error {
+	return reviewBatchChange(c, "approve", "Approved")
+}
+
+func batchChangeReject(c *cli.Context) error {
+	return reviewBatchChange(c, "reject", "Rejected")
+}
+
+func batchChangeCancel(c *cli.Context) error {
+	return reviewBatchChange(c, "cancel", "Cancelled")
+}
+
+// reviewBatchChange approves, rejects or cancels a batch change that is
+// pending review.
+func reviewBatchChange(c *cli.Context, action, done string) error {
+	id := c.String("batch-change-id")
+	var review interface{}
+	if action != "cancel" {
+		review = struct {
+			ReviewComment string `json:"reviewComment,omitempty"`
+		}{c.String("review-comment")}
 	}
 
-	if len(change) != 0 {
-		printBasicTable(change)
-	} else {
-		fmt.Println("No batch change found with id: " + c.String("batch-change-id"))
+	bc := &vinyldns.BatchRecordChange{}
+	err := request(client(c), "POST", fmt.Sprintf("/zones/batchrecordchanges/%s/%s", id, action), review, bc)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return printResult(c, bc, fmt.Sprintf("%s batch change %s", done, id))
 }
 
 func changeList(chs []vinyldns.RecordChange) string {
@@ -113,40 +123,98 @@ func changeList(chs []vinyldns.RecordChange) string {
 }
 
 func batchChangeCreate(c *cli.Context) error {
-	data := []byte(c.String("json"))
+	if path := c.String("csv"); path != "" {
+		return batchChangeCreateFromCSV(c, path)
+	}
+
 	batchChange := &vinyldns.BatchRecordChange{}
-	if err := json.Unmarshal(data, &batchChange); err != nil {
+	if err := readPayload(c, batchChange); err != nil {
+		return err
+	}
+	scheduled, err := scheduledTime(c, batchChange.ScheduledTime)
+	if err != nil {
 		return err
 	}
+	batchChange.ScheduledTime = scheduled
 	client := client(c)
-	bc, err := client.BatchRecordChangeCreate(batchChange)
+	created, err := client.BatchRecordChangeCreate(batchChange)
 	if err != nil {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(bc)
+	return printBatchChange(c, client, created)
+}
+
+func batchChangeCreateFromCSV(c *cli.Context, path string) error {
+	changes, ownerGroupID, ownerGroupName, err := readBatchChangeCSV(path)
+	if err != nil {
+		return err
+	}
+	scheduled, err := scheduledTime(c, "")
+	if err != nil {
+		return err
 	}
 
-	formattedData := [][]string{
-		{"ID", bc.ID},
-		{"UserName", bc.UserName},
-		{"UserID", bc.UserID},
-		{"Status", bc.Status},
-		{"Comments", bc.Comments},
-		{"Changes", changeList(bc.Changes)},
-		{"CreatedTimestamp", bc.CreatedTimestamp},
-		{"OwnerGroupID", bc.OwnerGroupID},
-		{"ApprovalStatus", bc.ApprovalStatus},
-		{"ReviewerID", bc.ReviewerID},
-		{"ReviewerUserName", bc.ReviewerUserName},
-		{"ReviewerTimestamp", bc.ReviewTimestamp},
-		{"ReviewComment", bc.ReviewComment},
-		{"ScheduledTime", bc.ScheduledTime},
-		{"CancelledTimestamp", bc.CancelledTimestamp},
+	client := client(c)
+	if ownerGroupName != "" {
+		if ownerGroupID, err = getGroupID(client, ownerGroupID, ownerGroupName); err != nil {
+			return err
+		}
 	}
 
-	printBasicTable(formattedData)
+	bc := batchChangePayload{
+		BatchRecordChange: vinyldns.BatchRecordChange{
+			Comments:      c.String("comments"),
+			OwnerGroupID:  ownerGroupID,
+			ScheduledTime: scheduled,
+		},
+		Changes: changes,
+	}
+	created, err := submitBatchChange(client, bc)
+	if err != nil {
+		return err
+	}
+
+	return printBatchChange(c, client, created)
+}
+
+// scheduledTime returns the --scheduled-time in the API's format, or current
+// if it is not passed.
+func scheduledTime(c *cli.Context, current string) (string, error) {
+	if !c.IsSet("scheduled-time") {
+		return current, nil
+	}
+
+	return parseScheduledTime(c.String("scheduled-time"), time.Now())
+}
+
+// printBatchChange prints a created batch change, first waiting for it to be
+// processed if --wait is passed.
+func printBatchChange(c *cli.Context, client *vinyldns.Client, created *vinyldns.BatchRecordChangeUpdateResponse) error {
+	var bc interface{} = created
+	if c.Bool("wait") {
+		done, err := waitForBatchChange(c, client, created.ID)
+		if err != nil {
+			return err
+		}
+		bc = done.batchChange()
+	}
 
-	return nil
+	return printItem(c, bc, []field{
+		{"ID", "ID"},
+		{"UserName", "UserName"},
+		{"UserID", "UserID"},
+		{"Status", "Status"},
+		{"Comments", "Comments"},
+		{"Changes", "Changes"},
+		{"CreatedTimestamp", "CreatedTimestamp"},
+		{"OwnerGroupID", "OwnerGroupID"},
+		{"ApprovalStatus", "ApprovalStatus"},
+		{"ReviewerID", "ReviewerID"},
+		{"ReviewerUserName", "ReviewerUserName"},
+		{"ReviewerTimestamp", "ReviewTimestamp"},
+		{"ReviewComment", "ReviewComment"},
+		{"ScheduledTime", "ScheduledTime"},
+		{"CancelledTimestamp", "CancelledTimestamp"},
+	})
 }
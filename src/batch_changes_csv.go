@@ -0,0 +1,305 @@
+/*
+Copyright 2018 Comcast Cable Communications Management, LLC
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/vinyldns/go-vinyldns/vinyldns"
+)
+
+// the columns of a batch change CSV file
+const (
+	changeTypeColumn     = "ChangeType"
+	inputNameColumn      = "InputName"
+	typeColumn           = "Type"
+	ttlColumn            = "TTL"
+	recordColumn         = "Record"
+	ownerGroupIDColumn   = "OwnerGroupID"
+	ownerGroupNameColumn = "OwnerGroupName"
+)
+
+// csvColumns maps the normalized headers a batch change CSV file may use to
+// its columns. Status, SystemMessage and the other columns batch-change
+// writes are accepted and ignored, so its output can be edited and submitted
+// again.
+var csvColumns = map[string]string{
+	"changetype":     changeTypeColumn,
+	"change":         changeTypeColumn,
+	"inputname":      inputNameColumn,
+	"name":           inputNameColumn,
+	"fqdn":           inputNameColumn,
+	"type":           typeColumn,
+	"recordtype":     typeColumn,
+	"ttl":            ttlColumn,
+	"record":         recordColumn,
+	"recorddata":     recordColumn,
+	"rdata":          recordColumn,
+	"data":           recordColumn,
+	"ownergroupid":   ownerGroupIDColumn,
+	"ownergroup":     ownerGroupIDColumn,
+	"ownergroupname": ownerGroupNameColumn,
+	"status":         "",
+	"systemmessage":  "",
+	"id":             "",
+	"zonename":       "",
+	"recordname":     "",
+}
+
+// batchChangeRow is a change of a batch change in the CSV column layout.
+type batchChangeRow struct {
+	ChangeType    string `json:"changeType"`
+	InputName     string `json:"inputName"`
+	Type          string `json:"type"`
+	TTL           string `json:"ttl,omitempty"`
+	Record        string `json:"record,omitempty"`
+	OwnerGroupID  string `json:"ownerGroupId,omitempty"`
+	Status        string `json:"status,omitempty"`
+	SystemMessage string `json:"systemMessage,omitempty"`
+}
+
+var batchChangeRowFields = fields("ChangeType", "InputName", "Type", "TTL", "Record", "OwnerGroupID", "Status", "SystemMessage")
+
+// csvChange is a row of a batch change CSV file, validated.
+type csvChange struct {
+	change         recordChange
+	ownerGroupID   string
+	ownerGroupName string
+}
+
+// readBatchChangeCSV reads the changes of a batch change from the CSV file at
+// path, or stdin if path is "-". The first row names the columns. Every row
+// is validated before any error is returned, so all of the bad lines are
+// reported at once.
+func readBatchChangeCSV(path string) ([]recordChange, string, string, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, "", "", err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	r := csv.NewReader(in)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, "", "", invalidError(fmt.Errorf("invalid CSV file %s: %w", path, err))
+	}
+	if len(rows) < 2 {
+		return nil, "", "", invalidError(fmt.Errorf("the CSV file %s has no changes", path))
+	}
+
+	columns, err := csvHeader(rows[0])
+	if err != nil {
+		return nil, "", "", invalidError(err)
+	}
+
+	changes := []recordChange{}
+	problems := []string{}
+	ownerGroupID, ownerGroupName := "", ""
+	for i, row := range rows[1:] {
+		line := i + 2
+		if blankRow(row) {
+			continue
+		}
+
+		ch, err := csvRowChange(columns, row)
+		if err == nil {
+			ownerGroupID, ownerGroupName, err = sameOwnerGroup(ownerGroupID, ownerGroupName, ch)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+		changes = append(changes, ch.change)
+	}
+
+	if len(problems) > 0 {
+		return nil, "", "", invalidError(fmt.Errorf("the CSV file %s has %d invalid lines:\n%s", path, len(problems), strings.Join(problems, "\n")))
+	}
+	if len(changes) == 0 {
+		return nil, "", "", invalidError(fmt.Errorf("the CSV file %s has no changes", path))
+	}
+
+	return changes, ownerGroupID, ownerGroupName, nil
+}
+
+// csvHeader maps the columns of the header row to their indexes.
+func csvHeader(header []string) (map[string]int, error) {
+	columns := map[string]int{}
+	for i, h := range header {
+		key := strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(h)))
+		col, ok := csvColumns[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown CSV column %q; the columns are %s", h, strings.Join([]string{
+				changeTypeColumn, inputNameColumn, typeColumn, ttlColumn, recordColumn, ownerGroupIDColumn, ownerGroupNameColumn,
+			}, ", "))
+		}
+		if col == "" {
+			continue
+		}
+		if _, ok := columns[col]; ok {
+			return nil, fmt.Errorf("the CSV column %s is given more than once", col)
+		}
+		columns[col] = i
+	}
+
+	for _, col := range []string{changeTypeColumn, inputNameColumn, typeColumn} {
+		if _, ok := columns[col]; !ok {
+			return nil, fmt.Errorf("the CSV file has no %s column", col)
+		}
+	}
+
+	return columns, nil
+}
+
+func blankRow(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func csvRowChange(columns map[string]int, row []string) (csvChange, error) {
+	value := func(col string) string {
+		i, ok := columns[col]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	ch := csvChange{ownerGroupID: value(ownerGroupIDColumn), ownerGroupName: value(ownerGroupNameColumn)}
+	rc := &ch.change.RecordChange
+
+	switch strings.ToLower(value(changeTypeColumn)) {
+	case "add":
+		rc.ChangeType = "Add"
+	case "deleterecordset", "delete":
+		rc.ChangeType = "DeleteRecordSet"
+	default:
+		return ch, invalidError(fmt.Errorf("change type %q must be Add or DeleteRecordSet", value(changeTypeColumn)))
+	}
+
+	if rc.InputName = value(inputNameColumn); rc.InputName == "" {
+		return ch, invalidError(errors.New("the input name is required"))
+	}
+
+	rc.Type = strings.ToUpper(value(typeColumn))
+	if !batchChangeTypes[rc.Type] {
+		return ch, invalidError(fmt.Errorf("batch changes do not support type %q", value(typeColumn)))
+	}
+
+	if ttl := value(ttlColumn); ttl != "" {
+		n, err := strconv.Atoi(ttl)
+		if err != nil || n <= 0 {
+			return ch, invalidError(fmt.Errorf("TTL %q must be a positive number", ttl))
+		}
+		rc.TTL = n
+	}
+
+	data := value(recordColumn)
+	if data == "" {
+		if rc.ChangeType == "Add" {
+			return ch, invalidError(errors.New("the record data is required to add a record"))
+		}
+		return ch, nil
+	}
+
+	records, err := parseRecordData(rc.Type, data)
+	if err != nil {
+		return ch, err
+	}
+	if len(records) != 1 {
+		return ch, invalidError(fmt.Errorf("each line must have one record; %q has %d", data, len(records)))
+	}
+	ch.change.Record = &records[0]
+
+	return ch, nil
+}
+
+// sameOwnerGroup checks that ch's owner group, if it has one, matches the
+// one earlier lines gave, since a batch change has a single owner group.
+func sameOwnerGroup(id, name string, ch csvChange) (string, string, error) {
+	if ch.ownerGroupID == "" && ch.ownerGroupName == "" {
+		return id, name, nil
+	}
+	if id == "" && name == "" {
+		return ch.ownerGroupID, ch.ownerGroupName, nil
+	}
+	if ch.ownerGroupID != id || ch.ownerGroupName != name {
+		return id, name, invalidError(errors.New("a batch change has one owner group, but this line's differs from earlier lines"))
+	}
+
+	return id, name, nil
+}
+
+// batchChangeRows returns the changes of a batch change in the CSV column
+// layout, with their status.
+func batchChangeRows(c *vinyldns.Client, id string) ([]batchChangeRow, error) {
+	bc := struct {
+		vinyldns.BatchRecordChange
+		Changes []struct {
+			recordChangeStatus
+			Record *record `json:"record,omitempty"`
+		} `json:"changes"`
+	}{}
+	if err := request(c, "GET", fmt.Sprintf("/zones/batchrecordchanges/%s", id), nil, &bc); err != nil {
+		return nil, err
+	}
+
+	rows := []batchChangeRow{}
+	for _, ch := range bc.Changes {
+		row := batchChangeRow{
+			ChangeType:    ch.ChangeType,
+			InputName:     ch.InputName,
+			Type:          ch.Type,
+			OwnerGroupID:  bc.OwnerGroupID,
+			Status:        ch.Status,
+			SystemMessage: ch.SystemMessage,
+		}
+		if ch.TTL > 0 {
+			row.TTL = strconv.Itoa(ch.TTL)
+		}
+		if ch.Record != nil {
+			row.Record = csvRecordData(ch.Type, *ch.Record)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// csvRecordData formats r the way --record-set-data and CSV files write it.
+func csvRecordData(t string, r record) string {
+	if t == "TXT" || t == "SPF" {
+		return r.Text
+	}
+
+	d, _ := formatRecordData(t, r)
+
+	return d
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2018 Comcast Cable Communications Management, LLC
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vinyldns/go-vinyldns/vinyldns"
+)
+
+// batchChangeLimit is the VinylDNS API's default limit on the number of
+// changes in one batch change.
+const batchChangeLimit = 1000
+
+// recordChange is a vinyldns.RecordChange whose record can hold the data of
+// every type batch changes support; vinyldns.RecordData only has A, AAAA,
+// CNAME and PTR data.
+type recordChange struct {
+	vinyldns.RecordChange
+	Record *record `json:"record,omitempty"`
+}
+
+// batchChangePayload is a vinyldns.BatchRecordChange made of recordChanges.
+type batchChangePayload struct {
+	vinyldns.BatchRecordChange
+	Changes []recordChange `json:"changes"`
+}
+
+// batchChangeTypes are the record types batch changes support.
+var batchChangeTypes = map[string]bool{
+	"A": true, "AAAA": true, "CNAME": true, "PTR": true, "TXT": true,
+	"MX": true, "NS": true, "SRV": true, "NAPTR": true,
+}
+
+// batchChangeSummary is a vinyldns.RecordChange, which go-vinyldns lists
+// batch changes as, with the review and scheduling fields of the summary.
+type batchChangeSummary struct {
+	vinyldns.RecordChange
+	ApprovalStatus string `json:"approvalStatus,omitempty"`
+	ReviewerName   string `json:"reviewerName,omitempty"`
+	ScheduledTime  string `json:"scheduledTime,omitempty"`
+}
+
+// listBatchChanges lists every page of batch changes, optionally only those
+// with the given approval status. ignoreAccess lists the batch changes of all
+// users, which only support users may do.
+func listBatchChanges(c *vinyldns.Client, approvalStatus string, ignoreAccess bool) ([]batchChangeSummary, error) {
+	summaries := []batchChangeSummary{}
+	startFrom := ""
+	for {
+		query := url.Values{}
+		if approvalStatus != "" {
+			query.Set("approvalStatus", approvalStatus)
+		}
+		if ignoreAccess {
+			query.Set("ignoreAccess", "true")
+		}
+		if startFrom != "" {
+			query.Set("startFrom", startFrom)
+		}
+
+		page := struct {
+			BatchChanges []batchChangeSummary `json:"batchChanges"`
+			NextID       interface{}          `json:"nextId"`
+		}{}
+		path := "/zones/batchrecordchanges"
+		if len(query) > 0 {
+			path += "?" + query.Encode()
+		}
+		if err := request(c, http.MethodGet, path, nil, &page); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, page.BatchChanges...)
+
+		if page.NextID == nil {
+			return summaries, nil
+		}
+		startFrom = fmt.Sprint(page.NextID)
+	}
+}
+
+// scheduledBatchChange is a batchChangeSummary with its scheduled time in the
+// local timezone.
+type scheduledBatchChange struct {
+	batchChangeSummary
+	LocalTime string `json:"localTime"`
+}
+
+// scheduledBatchChanges returns the batch changes scheduled for the future,
+// soonest first.
+func scheduledBatchChanges(summaries []batchChangeSummary, now time.Time) []scheduledBatchChange {
+	scheduled := []scheduledBatchChange{}
+	for _, s := range summaries {
+		t, err := time.Parse(time.RFC3339, s.ScheduledTime)
+		if err != nil || !t.After(now) {
+			continue
+		}
+		scheduled = append(scheduled, scheduledBatchChange{s, t.Local().Format("2006-01-02 15:04 MST")})
+	}
+	sort.SliceStable(scheduled, func(i, j int) bool {
+		return scheduled[i].ScheduledTime < scheduled[j].ScheduledTime
+	})
+
+	return scheduled
+}
+
+var clockTime = regexp.MustCompile(`^(today|tomorrow)(?:\s+(\d{1,2}):(\d{2}))?$`)
+
+// parseScheduledTime parses a --scheduled-time: RFC 3339, a local date and
+// time such as "2024-06-01 02:00", a duration from now such as "+2h" or
+// "+1d", or "today" or "tomorrow" with an optional local time such as
+// "tomorrow 02:00". It returns the time in the API's format.
+func parseScheduledTime(s string, now time.Time) (string, error) {
+	s = strings.TrimSpace(s)
+	var t time.Time
+	var err error
+
+	switch m := clockTime.FindStringSubmatch(strings.ToLower(s)); {
+	case strings.HasPrefix(s, "+"):
+		var d time.Duration
+		if days := strings.TrimSuffix(s[1:], "d"); days != s[1:] {
+			n, convErr := strconv.Atoi(days)
+			d, err = time.Duration(n)*24*time.Hour, convErr
+		} else {
+			d, err = time.ParseDuration(s[1:])
+		}
+		t = now.Add(d)
+	case m != nil:
+		y, mo, day := now.Date()
+		if m[1] == "tomorrow" {
+			day++
+		}
+		hour, min := 0, 0
+		if m[2] != "" {
+			hour, _ = strconv.Atoi(m[2])
+			min, _ = strconv.Atoi(m[3])
+			if hour > 23 || min > 59 {
+				err = fmt.Errorf("invalid time of day %s:%s", m[2], m[3])
+			}
+		}
+		t = time.Date(y, mo, day, hour, min, 0, 0, now.Location())
+	default:
+		t, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			t, err = time.ParseInLocation("2006-01-02 15:04", s, now.Location())
+		}
+	}
+	if err != nil {
+		return "", usageError(fmt.Errorf("invalid --scheduled-time %q; use RFC 3339, '2006-01-02 15:04', '+2h', '+1d' or 'tomorrow 02:00'", s))
+	}
+	if !t.After(now) {
+		return "", invalidError(fmt.Errorf("--scheduled-time %s is in the past", t.Local().Format("2006-01-02 15:04 MST")))
+	}
+
+	return t.UTC().Format(time.RFC3339), nil
+}
+
+func submitBatchChange(c *vinyldns.Client, bc batchChangePayload) (*vinyldns.BatchRecordChangeUpdateResponse, error) {
+	resp := &vinyldns.BatchRecordChangeUpdateResponse{}
+	err := request(c, http.MethodPost, "/zones/batchrecordchanges", bc, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// chunkChanges splits changes into batch changes of at most size changes,
+// each copying bc's other fields. Changes to the same record set are kept in
+// one batch change, since a later batch change could not add to it.
+func chunkChanges(bc batchChangePayload, changes []recordChange, size int) []batchChangePayload {
+	if size <= 0 {
+		size = batchChangeLimit
+	}
+
+	keys := []string{}
+	groups := map[string][]recordChange{}
+	for _, ch := range changes {
+		key := strings.ToLower(ch.InputName) + " " + ch.Type
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], ch)
+	}
+
+	chunks := []batchChangePayload{}
+	chunk := []recordChange{}
+	for _, key := range keys {
+		if len(chunk) > 0 && len(chunk)+len(groups[key]) > size {
+			chunks = append(chunks, withChanges(bc, chunk))
+			chunk = []recordChange{}
+		}
+		chunk = append(chunk, groups[key]...)
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, withChanges(bc, chunk))
+	}
+
+	return chunks
+}
+
+func withChanges(bc batchChangePayload, changes []recordChange) batchChangePayload {
+	bc.Changes = changes
+	return bc
+}
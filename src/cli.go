@@ -27,8 +27,31 @@ const hostFlag = "host"
 const accessKeyFlag = "access-key"
 const secretKeyFlag = "secret-key"
 const outputFlag = "output"
+const profileFlag = "profile"
+const configFlag = "config"
+const columnsFlag = "columns"
+const sortByFlag = "sort-by"
+const noHeadersFlag = "no-headers"
+const wideFlag = "wide"
+const showSecretsFlag = "show-secrets"
+const timeoutFlag = "timeout"
+const retriesFlag = "retries"
+const retryBackoffFlag = "retry-backoff"
+const caCertFlag = "ca-cert"
+const clientCertFlag = "client-cert"
+const clientKeyFlag = "client-key"
+const insecureSkipVerifyFlag = "insecure-skip-verify"
+const httpsProxyFlag = "https-proxy"
+const noProxyFlag = "no-proxy"
+const debugFlag = "debug"
 
 func main() {
+	// -v is short for --debug, so --version has no short form
+	cli.VersionFlag = cli.BoolFlag{
+		Name:  "version",
+		Usage: "print the version",
+	}
+
 	app := cli.NewApp()
 	app.Name = "vinyldns"
 	app.Version = version
@@ -51,11 +74,205 @@ func main() {
 		},
 		cli.StringFlag{
 			Name:   fmt.Sprintf("%s, op", outputFlag),
-			Usage:  "VinylDNS output format ('table' (default), 'json')",
+			Usage:  "VinylDNS output format ('table' (default), 'json', 'yaml', 'csv', 'tsv', 'template=<go-template>', 'jsonpath=<expression>')",
 			EnvVar: "VINYLDNS_FORMAT",
 		},
+		cli.StringFlag{
+			Name:   profileFlag,
+			Usage:  "The config file profile to read the host and keys from when they are not passed as flags or environment variables",
+			EnvVar: "VINYLDNS_PROFILE",
+		},
+		cli.StringFlag{
+			Name:   configFlag,
+			Usage:  "The config file path (default: ~/.config/vinyldns/config.yaml)",
+			EnvVar: "VINYLDNS_CONFIG",
+		},
+		cli.StringFlag{
+			Name:  columnsFlag,
+			Usage: "Comma-separated fields to show as columns in list output, e.g. 'Name,TTL,OwnerGroupID'",
+		},
+		cli.StringFlag{
+			Name:  sortByFlag,
+			Usage: "The field to sort list output by; prefix it with '-' to sort in descending order",
+		},
+		cli.BoolFlag{
+			Name:  noHeadersFlag,
+			Usage: "Omit the header row from table, csv and tsv output",
+		},
+		cli.BoolFlag{
+			Name:  wideFlag,
+			Usage: "Show more columns in list output",
+		},
+		cli.BoolFlag{
+			Name:  showSecretsFlag,
+			Usage: "Show TSIG and secret keys instead of masking them in output",
+		},
+		cli.StringFlag{
+			Name:   timeoutFlag,
+			Usage:  "How long to wait for each response from the VinylDNS API, e.g. '30s', or 0 to wait forever (default: 1m)",
+			EnvVar: "VINYLDNS_TIMEOUT",
+		},
+		cli.StringFlag{
+			Name:   retriesFlag,
+			Usage:  "How many times to retry GET requests that fail with a network error or 5xx status (default: 3)",
+			EnvVar: "VINYLDNS_RETRIES",
+		},
+		cli.StringFlag{
+			Name:   retryBackoffFlag,
+			Usage:  "The backoff before the first retry; it doubles, with jitter, for each later retry (default: 500ms)",
+			EnvVar: "VINYLDNS_RETRY_BACKOFF",
+		},
+		cli.StringFlag{
+			Name:   caCertFlag,
+			Usage:  "A PEM file of CA certificates to trust, as well as the system ones, when verifying the VinylDNS API",
+			EnvVar: "VINYLDNS_CA_CERT",
+		},
+		cli.StringFlag{
+			Name:   clientCertFlag,
+			Usage:  "A PEM client certificate to present to the VinylDNS API for mutual TLS; requires --client-key",
+			EnvVar: "VINYLDNS_CLIENT_CERT",
+		},
+		cli.StringFlag{
+			Name:   clientKeyFlag,
+			Usage:  "The PEM private key of --client-cert",
+			EnvVar: "VINYLDNS_CLIENT_KEY",
+		},
+		cli.BoolFlag{
+			Name:   insecureSkipVerifyFlag,
+			Usage:  "Do not verify the TLS certificate of the VinylDNS API. INSECURE: use --ca-cert instead where possible",
+			EnvVar: "VINYLDNS_INSECURE_SKIP_VERIFY",
+		},
+		cli.StringFlag{
+			Name:   httpsProxyFlag,
+			Usage:  "The proxy to reach the VinylDNS API through",
+			EnvVar: "HTTPS_PROXY,https_proxy",
+		},
+		cli.StringFlag{
+			Name:   noProxyFlag,
+			Usage:  "Comma-separated hosts and domains to reach without the proxy",
+			EnvVar: "NO_PROXY,no_proxy",
+		},
+		cli.BoolFlag{
+			Name:   fmt.Sprintf("%s, v", debugFlag),
+			Usage:  "Log each HTTP request to the VinylDNS API and its response to stderr, with signatures and keys masked",
+			EnvVar: "VINYLDNS_DEBUG",
+		},
 	}
+	var root *cli.Context
+	app.Before = func(c *cli.Context) error {
+		root = c
+		if err := validateOutput(c); err != nil {
+			return usageError(err)
+		}
+		return nil
+	}
+	app.OnUsageError = onUsageError
+	app.ExitErrHandler = exitWithError
 	app.Commands = []cli.Command{
+		{
+			Name:        "config",
+			Usage:       "config <list|show|set|delete>",
+			Description: "Manage the named connection profiles in the config file",
+			Subcommands: []cli.Command{
+				{
+					Name:        "list",
+					Usage:       "config list",
+					Description: "List all profiles",
+					Action:      configList,
+				},
+				{
+					Name:        "show",
+					Usage:       "config show --name <profile>",
+					Description: "View a profile, with its secret key masked unless --show-secrets is passed",
+					Action:      configShow,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:     "name",
+							Usage:    "The profile name",
+							Required: true,
+						},
+					},
+				},
+				{
+					Name:        "set",
+					Usage:       "config set --name <profile> [--host <host>] [--access-key <accessKey>] [--secret-key <secretKey>] [--timeout <duration>] [--retries <n>] [--retry-backoff <duration>] [--ca-cert <path>] [--client-cert <path> --client-key <path>] [--insecure-skip-verify] [--https-proxy <url>] [--no-proxy <hosts>] [--default]",
+					Description: "Create or update a profile, keeping any settings that are not passed",
+					Action:      configSet,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:     "name",
+							Usage:    "The profile name",
+							Required: true,
+						},
+						cli.StringFlag{
+							Name:  hostFlag,
+							Usage: "VinylDNS API Hostname",
+						},
+						cli.StringFlag{
+							Name:  accessKeyFlag,
+							Usage: "VinylDNS access key",
+						},
+						cli.StringFlag{
+							Name:  secretKeyFlag,
+							Usage: "VinylDNS secret key",
+						},
+						cli.StringFlag{
+							Name:  timeoutFlag,
+							Usage: "How long to wait for each response from the VinylDNS API",
+						},
+						cli.StringFlag{
+							Name:  retriesFlag,
+							Usage: "How many times to retry failed GET requests",
+						},
+						cli.StringFlag{
+							Name:  retryBackoffFlag,
+							Usage: "The backoff before the first retry",
+						},
+						cli.StringFlag{
+							Name:  caCertFlag,
+							Usage: "A PEM file of CA certificates to trust",
+						},
+						cli.StringFlag{
+							Name:  clientCertFlag,
+							Usage: "A PEM client certificate for mutual TLS",
+						},
+						cli.StringFlag{
+							Name:  clientKeyFlag,
+							Usage: "The PEM private key of --client-cert",
+						},
+						cli.BoolFlag{
+							Name:  insecureSkipVerifyFlag,
+							Usage: "Do not verify the TLS certificate of the VinylDNS API (INSECURE); pass --insecure-skip-verify=false to verify it again",
+						},
+						cli.StringFlag{
+							Name:  httpsProxyFlag,
+							Usage: "The proxy to reach the VinylDNS API through",
+						},
+						cli.StringFlag{
+							Name:  noProxyFlag,
+							Usage: "Comma-separated hosts and domains to reach without the proxy",
+						},
+						cli.BoolFlag{
+							Name:  "default",
+							Usage: "Use this profile when --profile is not passed",
+						},
+					},
+				},
+				{
+					Name:        "delete",
+					Usage:       "config delete --name <profile>",
+					Description: "Delete a profile",
+					Action:      configDelete,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:     "name",
+							Usage:    "The profile name",
+							Required: true,
+						},
+					},
+				},
+			},
+		},
 		{
 			Name:        "groups",
 			Usage:       "groups",
@@ -82,29 +299,42 @@ func main() {
 		},
 		{
 			Name:        "group-create",
-			Usage:       "group-create --json <groupJSON>",
-			Description: "Create a VinylDNS group",
-			Action:      groupCreate,
-			Flags: []cli.Flag{
+			Usage:       "group-create (--name <name> --email <email> [--description <description>] [--member <user>...] [--admin <user>...] | --json <groupJSON> | --file <path>)",
+			Description: "Create a VinylDNS group; VinylDNS makes the user creating it an admin",
+			Action: func(c *cli.Context) error {
+				return requireAtLeast(c, groupCreate, "name", "json", "file")
+			},
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
-					Name:     "json",
-					Usage:    "The VinylDNS JSON representing the group",
-					Required: true,
+					Name:  "name",
+					Usage: "The group name",
 				},
-			},
+				cli.StringFlag{
+					Name:  "email",
+					Usage: "The group email address",
+				},
+				cli.StringFlag{
+					Name:  "description",
+					Usage: "The group description",
+				},
+				cli.StringSliceFlag{
+					Name:  "member",
+					Usage: "The user ID or username of a member; repeat the flag for multiple members",
+				},
+				cli.StringSliceFlag{
+					Name:  "admin",
+					Usage: "The user ID or username of an admin, who is also made a member; repeat the flag for multiple admins",
+				},
+			}, payloadFlags("group")...),
 		},
 		{
 			Name:        "group-update",
-			Usage:       "group-update --json <groupJSON>",
+			Usage:       "group-update (--json <groupJSON> | --file <path>)",
 			Description: "Update a VinylDNS group",
-			Action:      groupUpdate,
-			Flags: []cli.Flag{
-				cli.StringFlag{
-					Name:     "json",
-					Usage:    "The VinylDNS JSON representing the group",
-					Required: true,
-				},
+			Action: func(c *cli.Context) error {
+				return requireAtLeast(c, groupUpdate, "json", "file")
 			},
+			Flags: payloadFlags("group"),
 		},
 		{
 			Name:        "group-delete",
@@ -145,6 +375,42 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:        "group-member-add",
+			Usage:       "group-member-add (--group-id <groupID> | --group-name <groupName>) --user <userID|userName> [--user <userID|userName>...]",
+			Description: "Add users to the members of a VinylDNS group",
+			Action: func(c *cli.Context) error {
+				return requireAtLeast(c, groupMemberAdd, "group-id", "group-name")
+			},
+			Flags: groupUserFlags,
+		},
+		{
+			Name:        "group-member-remove",
+			Usage:       "group-member-remove (--group-id <groupID> | --group-name <groupName>) --user <userID|userName> [--user <userID|userName>...]",
+			Description: "Remove users from the members (and admins) of a VinylDNS group",
+			Action: func(c *cli.Context) error {
+				return requireAtLeast(c, groupMemberRemove, "group-id", "group-name")
+			},
+			Flags: groupUserFlags,
+		},
+		{
+			Name:        "group-admin-add",
+			Usage:       "group-admin-add (--group-id <groupID> | --group-name <groupName>) --user <userID|userName> [--user <userID|userName>...]",
+			Description: "Add users to the admins (and members) of a VinylDNS group",
+			Action: func(c *cli.Context) error {
+				return requireAtLeast(c, groupAdminAdd, "group-id", "group-name")
+			},
+			Flags: groupUserFlags,
+		},
+		{
+			Name:        "group-admin-remove",
+			Usage:       "group-admin-remove (--group-id <groupID> | --group-name <groupName>) --user <userID|userName> [--user <userID|userName>...]",
+			Description: "Remove users from the admins of a VinylDNS group, keeping them as members",
+			Action: func(c *cli.Context) error {
+				return requireAtLeast(c, groupAdminRemove, "group-id", "group-name")
+			},
+			Flags: groupUserFlags,
+		},
 		{
 			Name:        "group-activity",
 			Usage:       "group-activity --group-id <groupID>",
@@ -182,6 +448,42 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:        "zone-acl-list",
+			Usage:       "zone-acl-list (--zone-id <zoneID> | --zone-name <zoneName>)",
+			Description: "List the ACL rules of a zone",
+			Action: func(c *cli.Context) error {
+				return requireAtLeast(c, zoneACLList, "zone-id", "zone-name")
+			},
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "zone-id",
+					Usage: "The zone ID",
+				},
+				cli.StringFlag{
+					Name:  "zone-name",
+					Usage: "The zone name (an alternative to --zone-id)",
+				},
+			},
+		},
+		{
+			Name:        "zone-acl-add",
+			Usage:       "zone-acl-add (--zone-id <zoneID> | --zone-name <zoneName>) --access-level <level> [--user <user> | --group-id <groupID> | --group-name <groupName>] [--record-mask <mask>] [--record-type <type>...] [--description <description>] [--wait [--timeout <duration>]]",
+			Description: "Add an ACL rule to a zone",
+			Action: func(c *cli.Context) error {
+				return requireAtLeast(c, zoneACLAdd, "zone-id", "zone-name")
+			},
+			Flags: append(aclRuleFlags, append([]cli.Flag{aclDescriptionFlag}, waitFlags...)...),
+		},
+		{
+			Name:        "zone-acl-remove",
+			Usage:       "zone-acl-remove (--zone-id <zoneID> | --zone-name <zoneName>) [--access-level <level>] [--user <user> | --group-id <groupID> | --group-name <groupName>] [--record-mask <mask>] [--record-type <type>...] [--wait [--timeout <duration>]]",
+			Description: "Remove the ACL rules of a zone that apply to the given user or group, record mask and record types",
+			Action: func(c *cli.Context) error {
+				return requireAtLeast(c, zoneACLRemove, "zone-id", "zone-name")
+			},
+			Flags: append(aclRuleFlags, waitFlags...),
+		},
 		{
 			Name:        "zone-details",
 			Usage:       "zone-details --zone-id <zoneID>",
@@ -198,12 +500,12 @@ func main() {
 		},
 		{
 			Name:        "zone-create",
-			Usage:       "zone-create --name <name> --email <email> --admin-group-id <adminGroupID> --transfer-connection-name <transferConnectionName> --transfer-connection-key <transferConnectionKey> --transfer-connection-key-name <transferConnectionKeyName> --transfer-connection-primary-server <transferConnectionPrimaryServer> --zone-connection-name <zoneConnectionName> --zone-connection-key <zoneConnectionKey> --zone-connection-key-name <zoneConnectionKeyName> --zone-connection-primary-server <zoneConnectionPrimaryServer>",
+			Usage:       "zone-create --name <name> --email <email> --admin-group-id <adminGroupID> --transfer-connection-name <transferConnectionName> --transfer-connection-key <transferConnectionKey> --transfer-connection-key-name <transferConnectionKeyName> --transfer-connection-primary-server <transferConnectionPrimaryServer> --zone-connection-name <zoneConnectionName> --zone-connection-key <zoneConnectionKey> --zone-connection-key-name <zoneConnectionKeyName> --zone-connection-primary-server <zoneConnectionPrimaryServer> [--wait [--timeout <duration>]]",
 			Description: "Create a zone",
 			Action: func(c *cli.Context) error {
 				return requireAtLeast(c, zoneCreate, "admin-group-id", "admin-group-name")
 			},
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:     "name",
 					Usage:    "The zone name",
@@ -222,44 +524,49 @@ func main() {
 					Name:  "admin-group-name",
 					Usage: "The zone admin group name (an alternative to admin-group-id)",
 				},
+			}, append(connectionFlags, waitFlags...)...),
+		},
+		{
+			Name:        "zone-update",
+			Usage:       "zone-update ((--zone-id <zoneID> | --zone-name <zoneName>) [--email <email>] [--admin-group-id <adminGroupID> | --admin-group-name <adminGroupName>] [--shared[=false]] [--backend-id <backendID>] [--zone-connection-key-name <keyName> --zone-connection-key <key> --zone-connection-primary-server <server>] [--transfer-connection-key-name <keyName> --transfer-connection-key <key> --transfer-connection-primary-server <server>] [--yes] | --json <zoneJSON> | --file <path>)",
+			Description: "Update a zone, either changing only the fields that are passed as flags or replacing it with a JSON payload",
+			Action: func(c *cli.Context) error {
+				return requireAtLeast(c, zoneUpdate, "zone-id", "zone-name", "json", "file")
+			},
+			Flags: append(append([]cli.Flag{
 				cli.StringFlag{
-					Name:  "transfer-connection-key-name",
-					Usage: "The zone transfer connection key name",
+					Name:  "zone-id",
+					Usage: "The ID of the zone to update",
 				},
 				cli.StringFlag{
-					Name:  "transfer-connection-key",
-					Usage: "The zone transfer connection key",
+					Name:  "zone-name",
+					Usage: "The name of the zone to update (an alternative to zone-id)",
 				},
 				cli.StringFlag{
-					Name:  "transfer-connection-primary-server",
-					Usage: "The zone transfer connection primary server",
+					Name:  "email",
+					Usage: "The new zone email",
 				},
 				cli.StringFlag{
-					Name:  "zone-connection-key-name",
-					Usage: "The zone connection key name",
+					Name:  "admin-group-id",
+					Usage: "The new zone admin group ID",
 				},
 				cli.StringFlag{
-					Name:  "zone-connection-key",
-					Usage: "The zone connection key",
+					Name:  "admin-group-name",
+					Usage: "The new zone admin group name (an alternative to admin-group-id)",
 				},
-				cli.StringFlag{
-					Name:  "zone-connection-primary-server",
-					Usage: "The zone zone connection primary server",
+				cli.BoolFlag{
+					Name:  "shared",
+					Usage: "Share the zone, or pass --shared=false to stop sharing it",
 				},
-			},
-		},
-		{
-			Name:        "zone-update",
-			Usage:       "zone-update --json <zoneJSON>",
-			Description: "update zone details",
-			Action:      zoneUpdate,
-			Flags: []cli.Flag{
 				cli.StringFlag{
-					Name:     "json",
-					Usage:    "The VinylDNS JSON representing the zone details",
-					Required: true,
+					Name:  "backend-id",
+					Usage: "The new zone backend ID",
 				},
-			},
+				cli.BoolFlag{
+					Name:  "yes, y",
+					Usage: "Update the zone without asking for confirmation",
+				},
+			}, connectionFlags...), payloadFlags("zone")...),
 		},
 		{
 			Name:        "zone-delete",
@@ -287,6 +594,67 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:        "zone-export",
+			Usage:       "zone-export --zone-name <zoneName> [--file <path>]",
+			Description: "Export a zone's record sets as a BIND-format zone file",
+			Action: func(c *cli.Context) error {
+				return requireAtLeast(c, zoneExport, "zone-id", "zone-name")
+			},
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "zone-id",
+					Usage: "The zone ID",
+				},
+				cli.StringFlag{
+					Name:  "zone-name",
+					Usage: "The zone name (an alternative to zone-id)",
+				},
+				cli.StringFlag{
+					Name:  "file, f",
+					Usage: "The file to write the zone file to (default: stdout)",
+				},
+			},
+		},
+		{
+			Name:        "zone-import",
+			Usage:       "zone-import --zone-name <zoneName> --file <path> [--dry-run]",
+			Description: "Import the records of a BIND-format zone file into a zone as batch changes",
+			Action:      zoneImport,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:     "zone-name",
+					Usage:    "The zone name; also the origin of the zone file until it sets $ORIGIN",
+					Required: true,
+				},
+				cli.StringFlag{
+					Name:     "file, f",
+					Usage:    "The zone file to import, or '-' for stdin",
+					Required: true,
+				},
+				cli.StringFlag{
+					Name:  "owner-group-id",
+					Usage: "The owner group ID of the imported record sets",
+				},
+				cli.StringFlag{
+					Name:  "owner-group-name",
+					Usage: "The owner group name of the imported record sets (an alternative to owner-group-id)",
+				},
+				cli.StringFlag{
+					Name:  "comments",
+					Usage: "The batch change comments",
+				},
+				cli.IntFlag{
+					Name:  "chunk-size",
+					Value: batchChangeLimit,
+					Usage: "The most changes to submit in one batch change",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Print the changes instead of submitting them",
+				},
+			},
+		},
 		{
 			Name:        "zone-changes",
 			Usage:       "zone-changes --zone-changes <zoneID>",
@@ -302,12 +670,12 @@ func main() {
 		},
 		{
 			Name:        "zone-sync",
-			Usage:       "zone-sync --zone-sync <zoneID>",
+			Usage:       "zone-sync --zone-sync <zoneID> [--wait [--timeout <duration>]]",
 			Description: "starts zone sync process",
 			Action: func(c *cli.Context) error {
 				return requireAtLeast(c, zoneSync, "zone-id", "zone-name")
 			},
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:  "zone-id",
 					Usage: "The zone ID",
@@ -316,7 +684,7 @@ func main() {
 					Name:  "zone-name",
 					Usage: "The zone name (an alternative to --zone-id)",
 				},
-			},
+			}, waitFlags...),
 		},
 		{
 			Name:        "record-set-changes",
@@ -376,12 +744,12 @@ func main() {
 		},
 		{
 			Name:        "record-set-create",
-			Usage:       "record-set-create --zone-id <zoneID> --record-set-name <recordSetName> --record-set-type <type> --record-set-ttl <TTL> --record-set-data <rdata>",
+			Usage:       "record-set-create --zone-id <zoneID> --record-set-name <recordSetName> --record-set-type <type> --record-set-ttl <TTL> --record-set-data <rdata> [--record-set-data <rdata>...] [--wait [--timeout <duration>]]",
 			Description: "add a record set in a zone",
 			Action: func(c *cli.Context) error {
 				return requireAtLeast(c, recordSetCreate, "zone-id", "zone-name")
 			},
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:  "zone-id",
 					Usage: "The zone ID",
@@ -405,19 +773,67 @@ func main() {
 					Usage:    "The record set TTL",
 					Required: true,
 				},
-				cli.StringFlag{
+				cli.StringSliceFlag{
 					Name:     "record-set-data",
-					Usage:    "The record set data",
+					Usage:    "The record set data in zone file syntax, e.g. '10 5 5060 sip.ok.' for SRV; repeat the flag or pass a comma-separated list for multiple records (MX data is <preference>,<exchange>)",
 					Required: true,
 				},
+			}, waitFlags...),
+		},
+		{
+			Name:        "record-set-update",
+			Usage:       "record-set-update --zone-id <zoneID> --record-set-id <recordSetID> [--record-set-ttl <TTL>] [--record-set-data <rdata>] [--owner-group-id <ownerGroupID>] [--wait [--timeout <duration>]]",
+			Description: "update a record set in a zone, keeping any fields that are not passed",
+			Action: func(c *cli.Context) error {
+				return requireAtLeast(c, func(c *cli.Context) error {
+					return requireAtLeast(c, recordSetUpdate, "record-set-id", "record-set-name")
+				}, "zone-id", "zone-name")
 			},
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "zone-id",
+					Usage: "The zone ID",
+				},
+				cli.StringFlag{
+					Name:  "zone-name",
+					Usage: "The zone name (an alternative to zone-id)",
+				},
+				cli.StringFlag{
+					Name:  "record-set-id",
+					Usage: "The record set ID",
+				},
+				cli.StringFlag{
+					Name:  "record-set-name",
+					Usage: "The record set name (an alternative to record-set-id; requires record-set-type)",
+				},
+				cli.StringFlag{
+					Name:  "record-set-type",
+					Usage: "The record set type (used with record-set-name)",
+				},
+				cli.StringFlag{
+					Name:  "record-set-ttl",
+					Usage: "The new record set TTL",
+				},
+				cli.StringSliceFlag{
+					Name:  "record-set-data",
+					Usage: "The new record set data, replacing all existing records; repeat the flag or pass a comma-separated list for multiple records",
+				},
+				cli.StringFlag{
+					Name:  "owner-group-id",
+					Usage: "The new record set owner group ID",
+				},
+				cli.StringFlag{
+					Name:  "owner-group-name",
+					Usage: "The new record set owner group name (an alternative to owner-group-id)",
+				},
+			}, waitFlags...),
 		},
 		{
 			Name:        "record-set-delete",
-			Usage:       "record-set-delete --zone-id <zoneID> --record-set-id <recordSetID>",
+			Usage:       "record-set-delete --zone-id <zoneID> --record-set-id <recordSetID> [--wait [--timeout <duration>]]",
 			Description: "delete record set in a zone",
 			Action:      recordSetDelete,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:     "zone-id",
 					Usage:    "The zone ID",
@@ -428,7 +844,7 @@ func main() {
 					Usage:    "The record set ID",
 					Required: true,
 				},
-			},
+			}, waitFlags...),
 		},
 		{
 			Name:        "record-sets",
@@ -483,9 +899,23 @@ func main() {
 		},
 		{
 			Name:        "batch-changes",
-			Usage:       "batch-changes",
+			Usage:       "batch-changes [--approval-status <status>] [--ignore-access] [--scheduled]",
 			Description: "List all batch changes",
 			Action:      batchChanges,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "approval-status",
+					Usage: "Only list batch changes with this approval status, e.g. 'PendingReview', 'AutoApproved', 'ManuallyApproved' or 'ManuallyRejected'",
+				},
+				cli.BoolFlag{
+					Name:  "ignore-access",
+					Usage: "List the batch changes of all users, not only your own (support users only)",
+				},
+				cli.BoolFlag{
+					Name:  "scheduled",
+					Usage: "Only list batch changes scheduled for the future, soonest first, in the local timezone",
+				},
+			},
 		},
 		{
 			Name:        "batch-change",
@@ -500,25 +930,139 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:        "batch-change-approve",
+			Usage:       "batch-change-approve --batch-change-id <batchChangeID> [--review-comment <comment>]",
+			Description: "Approve a batch change that is pending review",
+			Action:      batchChangeApprove,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:     "batch-change-id",
+					Usage:    "The batch change ID",
+					Required: true,
+				},
+				cli.StringFlag{
+					Name:  "review-comment",
+					Usage: "The review comment",
+				},
+			},
+		},
+		{
+			Name:        "batch-change-reject",
+			Usage:       "batch-change-reject --batch-change-id <batchChangeID> [--review-comment <comment>]",
+			Description: "Reject a batch change that is pending review",
+			Action:      batchChangeReject,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:     "batch-change-id",
+					Usage:    "The batch change ID",
+					Required: true,
+				},
+				cli.StringFlag{
+					Name:  "review-comment",
+					Usage: "The review comment",
+				},
+			},
+		},
+		{
+			Name:        "batch-change-cancel",
+			Usage:       "batch-change-cancel --batch-change-id <batchChangeID>",
+			Description: "Cancel a batch change that is pending review; only its creator can cancel it",
+			Action:      batchChangeCancel,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:     "batch-change-id",
+					Usage:    "The batch change ID",
+					Required: true,
+				},
+			},
+		},
 		{
 			Name:        "batch-change-create",
-			Usage:       "batch-change-create --json <batchChangeJSON>",
+			Usage:       "batch-change-create (--json <batchChangeJSON> | --file <path> | --csv <path> [--comments <comments>]) [--scheduled-time <time>] [--wait [--timeout <duration>]]",
 			Description: "Create a batch change",
-			Action:      batchChangeCreate,
+			Action: func(c *cli.Context) error {
+				return requireAtLeast(c, batchChangeCreate, "json", "file", "csv")
+			},
+			Flags: append(append(payloadFlags("batch change"),
+				cli.StringFlag{
+					Name:  "csv",
+					Usage: "A CSV file of the changes, with ChangeType, InputName, Type, TTL, Record and OwnerGroupID columns, or '-' for stdin",
+				},
+				cli.StringFlag{
+					Name:  "comments",
+					Usage: "The batch change comments, used with --csv",
+				},
+				cli.StringFlag{
+					Name:  "scheduled-time",
+					Usage: "When to process the batch change: RFC 3339, a local '2006-01-02 15:04', '+2h', '+1d' or 'tomorrow 02:00'",
+				},
+			), waitFlags...),
+		},
+		{
+			Name:        "api",
+			Usage:       "api <METHOD> <path> [--data <json> | --data @<path> | --data -]",
+			Description: "Send a signed request to any VinylDNS API endpoint, such as one the other commands do not cover, and print the response, e.g. 'vinyldns api GET /zones?nameFilter=ok'",
+			Action:      api,
 			Flags: []cli.Flag{
 				cli.StringFlag{
-					Name:     "json",
-					Usage:    "The VinylDNS JSON representing the batch change",
+					Name:  "data, d",
+					Usage: "The JSON or YAML request body, '@<path>' to read it from a file, or '-' for stdin",
+				},
+			},
+		},
+		{
+			Name:        "plan",
+			Usage:       "plan --file <manifest> [--prune]",
+			Description: "Show how the record sets of the zones in a YAML manifest differ from it",
+			Action:      plan,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:     "file, f",
+					Usage:    "The YAML manifest of the desired record sets, or '-' for stdin",
 					Required: true,
 				},
+				cli.BoolFlag{
+					Name:  "prune",
+					Usage: "Delete record sets that are not in the manifest (never the SOA or apex NS records)",
+				},
 			},
 		},
+		{
+			Name:        "apply",
+			Usage:       "apply --file <manifest> [--prune] [--yes] [--batch [--comments <comments>]] [--wait [--timeout <duration>]]",
+			Description: "Change the record sets of the zones in a YAML manifest to match it",
+			Action:      apply,
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:     "file, f",
+					Usage:    "The YAML manifest of the desired record sets, or '-' for stdin",
+					Required: true,
+				},
+				cli.BoolFlag{
+					Name:  "prune",
+					Usage: "Delete record sets that are not in the manifest (never the SOA or apex NS records)",
+				},
+				cli.BoolFlag{
+					Name:  "yes, y",
+					Usage: "Apply the changes without asking for confirmation",
+				},
+				cli.BoolFlag{
+					Name:  "batch",
+					Usage: "Submit all of the changes as one batch change",
+				},
+				cli.StringFlag{
+					Name:  "comments",
+					Usage: "The batch change comments",
+				},
+			}, waitFlags...),
+		},
 	}
-	err := app.Run(os.Args)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
-	}
+	prepareCommands(app.Commands)
+
+	// errors that urfave/cli returns without calling ExitErrHandler are
+	// reported with the context Before saw, if it got that far
+	exitWithError(root, app.Run(os.Args))
 }
 
 func requireAtLeast(c *cli.Context, action func(*cli.Context) error, flags ...string) error {
@@ -539,7 +1083,7 @@ func requireAtLeast(c *cli.Context, action func(*cli.Context) error, flags ...st
 		for i := range flags {
 			prefixedFlags[i] = fmt.Sprintf("'--%s'", flags[i])
 		}
-		return fmt.Errorf("one of the flags must be provided: %s", strings.Join(prefixedFlags, ", "))
+		return usageError(fmt.Errorf("one of the flags must be provided: %s", strings.Join(prefixedFlags, ", ")))
 	}
 	return action(c)
 }
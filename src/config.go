@@ -0,0 +1,333 @@
+/*
+Copyright 2018 Comcast Cable Communications Management, LLC
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v3"
+)
+
+// profile holds the connection settings for one VinylDNS instance. Its keys
+// match the names of the global flags they stand in for.
+type profile struct {
+	Host      string `yaml:"host,omitempty" json:"host,omitempty"`
+	AccessKey string `yaml:"access-key,omitempty" json:"accessKey,omitempty"`
+	SecretKey string `yaml:"secret-key,omitempty" json:"secretKey,omitempty"`
+
+	Timeout      string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Retries      string `yaml:"retries,omitempty" json:"retries,omitempty"`
+	RetryBackoff string `yaml:"retry-backoff,omitempty" json:"retryBackoff,omitempty"`
+
+	CACert             string `yaml:"ca-cert,omitempty" json:"caCert,omitempty"`
+	ClientCert         string `yaml:"client-cert,omitempty" json:"clientCert,omitempty"`
+	ClientKey          string `yaml:"client-key,omitempty" json:"clientKey,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure-skip-verify,omitempty" json:"insecureSkipVerify,omitempty"`
+	HTTPSProxy         string `yaml:"https-proxy,omitempty" json:"httpsProxy,omitempty"`
+	NoProxy            string `yaml:"no-proxy,omitempty" json:"noProxy,omitempty"`
+}
+
+// config is the contents of the CLI config file.
+type config struct {
+	DefaultProfile string             `yaml:"default-profile,omitempty"`
+	Profiles       map[string]profile `yaml:"profiles"`
+}
+
+// get returns the profile's value for the global flag of the same name.
+func (p profile) get(flag string) string {
+	switch flag {
+	case hostFlag:
+		return p.Host
+	case accessKeyFlag:
+		return p.AccessKey
+	case secretKeyFlag:
+		return p.SecretKey
+	case timeoutFlag:
+		return p.Timeout
+	case retriesFlag:
+		return p.Retries
+	case retryBackoffFlag:
+		return p.RetryBackoff
+	case caCertFlag:
+		return p.CACert
+	case clientCertFlag:
+		return p.ClientCert
+	case clientKeyFlag:
+		return p.ClientKey
+	case httpsProxyFlag:
+		return p.HTTPSProxy
+	case noProxyFlag:
+		return p.NoProxy
+	}
+	return ""
+}
+
+// masked returns a copy of p that is safe to display.
+func (p profile) masked() profile {
+	p.SecretKey = maskSecret(p.SecretKey)
+	return p
+}
+
+// profileView is a named profile as displayed by the config commands.
+type profileView struct {
+	Name string `json:"name"`
+	profile
+	Default bool `json:"default"`
+}
+
+// newProfileView returns the named profile for display, masking its secret
+// key unless --show-secrets is passed.
+func newProfileView(c *cli.Context, cfg *config, name string) profileView {
+	p := cfg.Profiles[name]
+	if !c.GlobalBool(showSecretsFlag) {
+		p = p.masked()
+	}
+
+	return profileView{
+		Name:    name,
+		profile: p,
+		Default: name == cfg.DefaultProfile,
+	}
+}
+
+// maskSecret hides all of s, so that no part of a secret ends up in logs.
+func maskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return "****"
+}
+
+func configPath(c *cli.Context) (string, error) {
+	if p := c.GlobalString(configFlag); p != "" {
+		return p, nil
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "vinyldns", "config.yaml"), nil
+}
+
+// loadConfig reads the config file; a missing file is an empty config.
+func loadConfig(c *cli.Context) (*config, error) {
+	cfg := &config{Profiles: map[string]profile{}}
+	path, err := configPath(c)
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]profile{}
+	}
+
+	return cfg, nil
+}
+
+func saveConfig(c *cli.Context, cfg *config) error {
+	path, err := configPath(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+// currentProfile returns the profile selected by --profile, or the config
+// file's default profile. Having no profile at all is not an error.
+func currentProfile(c *cli.Context) (profile, error) {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return profile{}, err
+	}
+
+	name := c.GlobalString(profileFlag)
+	if name == "" {
+		name = cfg.DefaultProfile
+	}
+	if name == "" {
+		return profile{}, nil
+	}
+
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return p, notFoundError("Profile %s not found", name)
+	}
+
+	return p, nil
+}
+
+// setting resolves a global setting: an explicit flag wins, then its
+// environment variable, then the current profile.
+func setting(c *cli.Context, p profile, flag string) string {
+	if v := c.GlobalString(flag); v != "" {
+		return v
+	}
+
+	return p.get(flag)
+}
+
+func configList(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	profiles := []profileView{}
+	for _, name := range names {
+		profiles = append(profiles, newProfileView(c, cfg, name))
+	}
+
+	return printList(c, profiles, fields("Name", "Host", "Default"), nil, "profiles")
+}
+
+func configShow(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	name := c.String("name")
+	if _, ok := cfg.Profiles[name]; !ok {
+		return notFoundError("Profile %s not found", name)
+	}
+
+	return printItem(c, newProfileView(c, cfg, name), fields("Name", "Host", "AccessKey", "SecretKey", "Timeout", "Retries", "RetryBackoff",
+		"CACert", "ClientCert", "ClientKey", "InsecureSkipVerify", "HTTPSProxy", "NoProxy", "Default"))
+}
+
+func configSet(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	name := strings.TrimSpace(c.String("name"))
+	if name == "" {
+		return usageError(errors.New("--name is required"))
+	}
+
+	p := cfg.Profiles[name]
+	if c.IsSet(hostFlag) {
+		p.Host = c.String(hostFlag)
+	}
+	if c.IsSet(accessKeyFlag) {
+		p.AccessKey = c.String(accessKeyFlag)
+	}
+	if c.IsSet(secretKeyFlag) {
+		p.SecretKey = c.String(secretKeyFlag)
+	}
+	if c.IsSet(timeoutFlag) {
+		p.Timeout = c.String(timeoutFlag)
+	}
+	if c.IsSet(retriesFlag) {
+		p.Retries = c.String(retriesFlag)
+	}
+	if c.IsSet(retryBackoffFlag) {
+		p.RetryBackoff = c.String(retryBackoffFlag)
+	}
+	if c.IsSet(caCertFlag) {
+		p.CACert = c.String(caCertFlag)
+	}
+	if c.IsSet(clientCertFlag) {
+		p.ClientCert = c.String(clientCertFlag)
+	}
+	if c.IsSet(clientKeyFlag) {
+		p.ClientKey = c.String(clientKeyFlag)
+	}
+	if c.IsSet(insecureSkipVerifyFlag) {
+		p.InsecureSkipVerify = c.Bool(insecureSkipVerifyFlag)
+	}
+	if c.IsSet(httpsProxyFlag) {
+		p.HTTPSProxy = c.String(httpsProxyFlag)
+	}
+	if c.IsSet(noProxyFlag) {
+		p.NoProxy = c.String(noProxyFlag)
+	}
+	if _, err := parseTransportSettings(p.get, p.InsecureSkipVerify); err != nil {
+		return usageError(err)
+	}
+	cfg.Profiles[name] = p
+
+	if c.Bool("default") || len(cfg.Profiles) == 1 {
+		cfg.DefaultProfile = name
+	}
+
+	if err := saveConfig(c, cfg); err != nil {
+		return err
+	}
+
+	return printResult(c, newProfileView(c, cfg, name), fmt.Sprintf("Saved profile %s", name))
+}
+
+func configDelete(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	name := c.String("name")
+	if _, ok := cfg.Profiles[name]; !ok {
+		return notFoundError("Profile %s not found", name)
+	}
+
+	delete(cfg.Profiles, name)
+	if cfg.DefaultProfile == name {
+		cfg.DefaultProfile = ""
+	}
+
+	if err := saveConfig(c, cfg); err != nil {
+		return err
+	}
+
+	return printResult(c, map[string]string{"name": name}, fmt.Sprintf("Deleted profile %s", name))
+}
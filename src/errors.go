@@ -0,0 +1,227 @@
+/*
+Copyright 2018 Comcast Cable Communications Management, LLC
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli"
+	"github.com/vinyldns/go-vinyldns/vinyldns"
+)
+
+// the exit codes of failed commands, as documented in the README
+const (
+	exitError        = 1 // any other failure
+	exitUsage        = 2 // unknown or missing flags, invalid settings
+	exitNotFound     = 3 // the zone, record set, group, user or profile does not exist
+	exitUnauthorized = 4 // the API rejected the credentials or denied access
+	exitInvalid      = 5 // the input is invalid, or the API rejected it as such
+	exitNetwork      = 6 // the API could not be reached
+	exitServer       = 7 // the API failed with a 5xx status
+	exitTimeout      = 8 // --wait timed out before the change completed
+)
+
+// exitCodeNames are the codes of the JSON errors written in JSON output mode.
+var exitCodeNames = map[int]string{
+	exitError:        "error",
+	exitUsage:        "usage",
+	exitNotFound:     "not_found",
+	exitUnauthorized: "unauthorized",
+	exitInvalid:      "invalid",
+	exitNetwork:      "network",
+	exitServer:       "server_error",
+	exitTimeout:      "timeout",
+}
+
+// cliError is an error the CLI detected itself, with the exit code to fail
+// with.
+type cliError struct {
+	code int
+	err  error
+}
+
+func (e *cliError) Error() string {
+	return e.err.Error()
+}
+
+func (e *cliError) Unwrap() error {
+	return e.err
+}
+
+func usageError(err error) error {
+	return &cliError{code: exitUsage, err: err}
+}
+
+func notFoundError(format string, a ...interface{}) error {
+	return &cliError{code: exitNotFound, err: fmt.Errorf(format, a...)}
+}
+
+func invalidError(err error) error {
+	return &cliError{code: exitInvalid, err: err}
+}
+
+func timeoutError(format string, a ...interface{}) error {
+	return &cliError{code: exitTimeout, err: fmt.Errorf(format, a...)}
+}
+
+// onUsageError fails with the error urfave/cli found parsing the flags,
+// rather than printing it with the help text to stdout.
+func onUsageError(c *cli.Context, err error, _ bool) error {
+	if c.Command.Name != "" {
+		return usageError(fmt.Errorf("%v; see '%s %s --help'", err, c.App.Name, c.Command.Name))
+	}
+
+	return usageError(fmt.Errorf("%v; see '%s --help'", err, c.App.Name))
+}
+
+// prepareCommands makes cmds and their subcommands fail with usage errors
+// when their flags are invalid or their required flags are missing. urfave/cli
+// would print the help text to stdout for either, so cmds check their
+// required flags themselves, before they run.
+func prepareCommands(cmds []cli.Command) {
+	for i := range cmds {
+		cmd := &cmds[i]
+		cmd.OnUsageError = onUsageError
+
+		// the flags may be shared with other commands
+		cmd.Flags = append([]cli.Flag{}, cmd.Flags...)
+		required := []string{}
+		for j, f := range cmd.Flags {
+			switch f := f.(type) {
+			case cli.StringFlag:
+				if f.Required {
+					f.Required = false
+					cmd.Flags[j] = f
+					required = append(required, f.Name)
+				}
+			case cli.StringSliceFlag:
+				if f.Required {
+					f.Required = false
+					cmd.Flags[j] = f
+					required = append(required, f.Name)
+				}
+			}
+		}
+		if len(required) > 0 {
+			cmd.Before = requireFlags(required)
+		}
+
+		prepareCommands(cmd.Subcommands)
+	}
+}
+
+// requireFlags returns a cli.BeforeFunc that fails unless each of the flags,
+// named the way cli.Flag names them, is set.
+func requireFlags(flags []string) cli.BeforeFunc {
+	return func(c *cli.Context) error {
+		missing := []string{}
+		for _, f := range flags {
+			name := strings.TrimSpace(strings.Split(f, ",")[0])
+			if !c.IsSet(name) {
+				missing = append(missing, "--"+name)
+			}
+		}
+
+		switch len(missing) {
+		case 0:
+			return nil
+		case 1:
+			return usageError(fmt.Errorf("%s is required; see '%s %s --help'", missing[0], c.App.Name, c.Command.Name))
+		default:
+			return usageError(fmt.Errorf("%s are required; see '%s %s --help'", strings.Join(missing, ", "), c.App.Name, c.Command.Name))
+		}
+	}
+}
+
+// exitCode classifies err, returning the exit code to fail with and the HTTP
+// status of the API response that caused it, if any.
+func exitCode(err error) (int, int) {
+	var ce *cliError
+	if errors.As(err, &ce) {
+		return ce.code, 0
+	}
+
+	var ve *vinyldns.Error
+	if errors.As(err, &ve) {
+		switch s := ve.ResponseCode; {
+		case s == http.StatusNotFound:
+			return exitNotFound, s
+		case s == http.StatusUnauthorized || s == http.StatusForbidden:
+			return exitUnauthorized, s
+		case s == http.StatusBadRequest || s == http.StatusConflict || s == http.StatusUnprocessableEntity:
+			return exitInvalid, s
+		case s >= 500:
+			return exitServer, s
+		default:
+			return exitError, s
+		}
+	}
+
+	var ue *url.Error
+	var ne net.Error
+	if errors.As(err, &ue) || errors.As(err, &ne) {
+		return exitNetwork, 0
+	}
+
+	return exitError, 0
+}
+
+// errorMessage returns the message of err: the API's response body for an
+// API error, since the rest of its text repeats the request.
+func errorMessage(err error) string {
+	var ve *vinyldns.Error
+	if errors.As(err, &ve) {
+		m := strings.TrimSpace(ve.ResponseBody)
+		var quoted string
+		if json.Unmarshal([]byte(m), &quoted) == nil {
+			m = quoted
+		}
+		if m != "" {
+			return m
+		}
+		return http.StatusText(ve.ResponseCode)
+	}
+
+	return err.Error()
+}
+
+// exitWithError reports err to stderr and exits with its exit code. In JSON
+// output mode the report is a JSON object with the code, message and HTTP
+// status; otherwise it is the error text. Secrets are masked in both.
+func exitWithError(c *cli.Context, err error) {
+	if err == nil {
+		return
+	}
+
+	code, status := exitCode(err)
+	if c != nil && outputFormat(c) == jsonOutput {
+		j, _ := json.Marshal(struct {
+			Code       string `json:"code"`
+			ExitCode   int    `json:"exitCode"`
+			Message    string `json:"message"`
+			HTTPStatus int    `json:"httpStatus,omitempty"`
+		}{exitCodeNames[code], code, redactText(errorMessage(err)), status})
+		fmt.Fprintln(os.Stderr, string(j))
+	} else {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", redactText(err.Error()))
+	}
+
+	os.Exit(code)
+}
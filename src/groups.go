@@ -13,8 +13,8 @@ limitations under the License.
 package main
 
 import (
-	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/urfave/cli"
 	"github.com/vinyldns/go-vinyldns/vinyldns"
@@ -27,29 +27,7 @@ func groups(c *cli.Context) error {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(groups)
-	}
-
-	return printGroupsTable(groups)
-}
-
-func printGroupsTable(groups []vinyldns.Group) error {
-	data := [][]string{}
-	for _, g := range groups {
-		data = append(data, []string{
-			g.Name,
-			g.ID,
-		})
-	}
-
-	if len(data) != 0 {
-		printTableWithHeaders([]string{"Name", "ID"}, data)
-	} else {
-		fmt.Printf("No groups found")
-	}
-
-	return nil
+	return printList(c, groups, fields("Name", "ID"), fields("Name", "ID", "Email", "Description", "Status", "Created"), "groups")
 }
 
 func group(c *cli.Context) error {
@@ -60,50 +38,71 @@ func group(c *cli.Context) error {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(g)
-	}
+	return printItem(c, g, fields("Name", "ID", "Email", "Description", "Status", "Members", "Admins"))
+}
 
-	data := [][]string{
-		{"Name", g.Name},
-		{"ID", g.ID},
-		{"Email", g.Email},
-		{"Description", g.Description},
-		{"Status", g.Status},
-		{"Members", userIDList(g.Members)},
-		{"Admins", userIDList(g.Admins)},
+func groupCreate(c *cli.Context) error {
+	if c.String("json") == "" && c.String("file") == "" {
+		return groupCreateFromFlags(c)
 	}
 
-	printBasicTable(data)
+	group := &vinyldns.Group{}
+	if err := readPayload(c, group); err != nil {
+		return err
+	}
+	client := client(c)
+	create, err := client.GroupCreate(group)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return printResult(c, create, fmt.Sprintf("Created group %s", group.Name))
 }
 
-func groupCreate(c *cli.Context) error {
-	data := []byte(c.String("json"))
-	group := &vinyldns.Group{}
-	if err := json.Unmarshal(data, &group); err != nil {
+// groupCreateFromFlags creates a group from --name, --email, --description,
+// --member and --admin. Admins are members too. The VinylDNS API adds the
+// user creating the group to its admins and members itself, so the CLI does
+// not look that user up and they need not be passed.
+func groupCreateFromFlags(c *cli.Context) error {
+	email, err := getOption(c, "email")
+	if err != nil {
 		return err
 	}
+
 	client := client(c)
-	create, err := client.GroupCreate(group)
+	members, err := userRefs(client, c.StringSlice("member"))
 	if err != nil {
 		return err
 	}
+	admins, err := userRefs(client, c.StringSlice("admin"))
+	if err != nil {
+		return err
+	}
+	for _, a := range admins {
+		if !hasUser(members, a.ID) {
+			members = append(members, a)
+		}
+	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(create)
+	group := &vinyldns.Group{
+		Name:        c.String("name"),
+		Email:       email,
+		Description: c.String("description"),
+		Members:     members,
+		Admins:      admins,
 	}
 
-	fmt.Printf("Created group %s\n", group.Name)
+	create, err := client.GroupCreate(group)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return printResult(c, create, fmt.Sprintf("Created group %s", create.Name))
 }
 
 func groupUpdate(c *cli.Context) error {
-	data := []byte(c.String("json"))
 	group := &vinyldns.Group{}
-	if err := json.Unmarshal(data, &group); err != nil {
+	if err := readPayload(c, group); err != nil {
 		return err
 	}
 	client := client(c)
@@ -111,11 +110,7 @@ func groupUpdate(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(updated)
-	}
-	fmt.Printf("Updated group %s\n", updated.Name)
-	return nil
+	return printResult(c, updated, fmt.Sprintf("Updated group %s", updated.Name))
 }
 
 func groupDelete(c *cli.Context) error {
@@ -126,13 +121,7 @@ func groupDelete(c *cli.Context) error {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(deleted)
-	}
-
-	fmt.Printf("Deleted group %s\n", id)
-
-	return nil
+	return printResult(c, deleted, fmt.Sprintf("Deleted group %s", id))
 }
 
 func groupAdmins(c *cli.Context) error {
@@ -142,13 +131,7 @@ func groupAdmins(c *cli.Context) error {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(admins)
-	}
-
-	printUsers(admins)
-
-	return nil
+	return printList(c, admins, userFields, nil, "admins")
 }
 
 func groupMembers(c *cli.Context) error {
@@ -158,44 +141,105 @@ func groupMembers(c *cli.Context) error {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(members)
+	return printList(c, members, userFields, nil, "members")
+}
+
+func groupActivity(c *cli.Context) error {
+	client := client(c)
+	activity, err := client.GroupActivity(c.String("group-id"))
+	if err != nil {
+		return err
 	}
 
-	printUsers(members)
+	return printList(c, activity.Changes, []field{
+		{"Created", "Created"},
+		{"UserID", "UserID"},
+		{"ChangeType", "ChangeType"},
+		{"NewGroup", "NewGroup.Name"},
+		{"NewGroupStatus", "NewGroup.Status"},
+		{"NewGroupID", "NewGroup.ID"},
+		{"OldGroup", "OldGroup.Name"},
+		{"OldGroupStatus", "OldGroup.Status"},
+		{"OldGroupID", "OldGroup.ID"},
+	}, nil, "group changes")
+}
 
-	return nil
+func groupMemberAdd(c *cli.Context) error {
+	return changeGroupUsers(c, false, true)
 }
 
-func groupActivity(c *cli.Context) error {
+func groupMemberRemove(c *cli.Context) error {
+	return changeGroupUsers(c, false, false)
+}
+
+func groupAdminAdd(c *cli.Context) error {
+	return changeGroupUsers(c, true, true)
+}
+
+func groupAdminRemove(c *cli.Context) error {
+	return changeGroupUsers(c, true, false)
+}
+
+// changeGroupUsers adds or removes the --user users to or from a group's
+// members or admins. Admins are always members too, so adding an admin also
+// adds them as a member and removing a member also removes them as an admin.
+// Users who are already in the wanted state are skipped, and the group is
+// only updated if anything changed.
+func changeGroupUsers(c *cli.Context, admins, add bool) error {
 	client := client(c)
-	activity, err := client.GroupActivity(c.String("group-id"))
+	g, err := getGroup(client, c.String("group-name"), c.String("group-id"))
 	if err != nil {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(activity)
+	role := "members"
+	if admins {
+		role = "admins"
 	}
 
-	for _, c := range activity.Changes {
-		fmt.Println("Changes...")
+	changed := []string{}
+	for _, name := range c.StringSlice("user") {
+		u, err := getUser(client, name)
+		if err != nil {
+			return err
+		}
 
-		data := [][]string{
-			{"Created", c.Created},
-			{"UserID", c.UserID},
-			{"ChangeType", c.ChangeType},
+		switch {
+		case add && admins && !hasUser(g.Admins, u.ID):
+			g.Admins = append(g.Admins, vinyldns.User{ID: u.ID})
+			if !hasUser(g.Members, u.ID) {
+				g.Members = append(g.Members, vinyldns.User{ID: u.ID})
+			}
+		case add && !admins && !hasUser(g.Members, u.ID):
+			g.Members = append(g.Members, vinyldns.User{ID: u.ID})
+		case !add && admins && hasUser(g.Admins, u.ID):
+			g.Admins = withoutUser(g.Admins, u.ID)
+		case !add && !admins && hasUser(g.Members, u.ID):
+			g.Members = withoutUser(g.Members, u.ID)
+			g.Admins = withoutUser(g.Admins, u.ID)
+		default:
+			continue
 		}
+		changed = append(changed, u.UserName)
+	}
 
-		printBasicTable(data)
+	if len(changed) == 0 {
+		state := "already"
+		if !add {
+			state = "not"
+		}
+		return printResult(c, g, fmt.Sprintf("No changes; the users are %s %s of group %s", state, role, g.Name))
+	}
 
-		fmt.Println("\n\nNew Group...")
-		printGroup(c.NewGroup)
+	updated, err := client.GroupUpdate(g.ID, g)
+	if err != nil {
+		return err
+	}
 
-		fmt.Println("\n\nOld Group...")
-		printGroup(c.OldGroup)
-		fmt.Println("\n=====")
+	verb := "Added %s to the %s of group %s"
+	if !add {
+		verb = "Removed %s from the %s of group %s"
 	}
 
-	return nil
+	return printResult(c, updated, fmt.Sprintf(verb, strings.Join(changed, ", "), role, g.Name))
 }
@@ -13,9 +13,11 @@ limitations under the License.
 package main
 
 import (
-	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
 
+	"github.com/urfave/cli"
 	"github.com/vinyldns/go-vinyldns/vinyldns"
 )
 
@@ -40,10 +42,10 @@ func groupByName(c *vinyldns.Client, name string) (*vinyldns.Group, error) {
 		}
 	}
 
-	return g, fmt.Errorf("Group %s not found", name)
+	return g, notFoundError("Group %s not found", name)
 }
 
-func getAdminGroupID(c *vinyldns.Client, id, name string) (string, error) {
+func getGroupID(c *vinyldns.Client, id, name string) (string, error) {
 	if id != "" {
 		return id, nil
 	}
@@ -56,37 +58,91 @@ func getAdminGroupID(c *vinyldns.Client, id, name string) (string, error) {
 	return g.ID, nil
 }
 
-func userIDList(mems []vinyldns.User) string {
-	members := []string{}
+// getUser looks a user up by ID or username, both of which the VinylDNS
+// API accepts.
+func getUser(c *vinyldns.Client, user string) (*vinyldns.User, error) {
+	u := &vinyldns.User{}
+	if err := request(c, http.MethodGet, "/users/"+url.PathEscape(user), nil, u); err != nil {
+		if e, ok := err.(*vinyldns.Error); ok && e.ResponseCode == http.StatusNotFound {
+			return nil, notFoundError("User %s not found", user)
+		}
+		return nil, err
+	}
 
-	for _, m := range mems {
-		members = append(members, m.ID)
+	return u, nil
+}
+
+// userRefs looks up users by ID or username and returns them as the user
+// references a group is made of, without duplicates.
+func userRefs(c *vinyldns.Client, users []string) ([]vinyldns.User, error) {
+	refs := []vinyldns.User{}
+	for _, user := range users {
+		u, err := getUser(c, user)
+		if err != nil {
+			return nil, err
+		}
+		if !hasUser(refs, u.ID) {
+			refs = append(refs, vinyldns.User{ID: u.ID})
+		}
 	}
 
-	return strings.Join(members, ", ")
+	return refs, nil
 }
 
-func printUsers(users []vinyldns.User) {
+func hasUser(users []vinyldns.User, id string) bool {
 	for _, u := range users {
-		data := [][]string{
-			{"UserName", u.UserName},
-			{"Name", u.FirstName + " " + u.LastName},
-			{"ID", u.ID},
-			{"Email", u.Email},
-			{"Created", u.Created},
+		if u.ID == id {
+			return true
 		}
+	}
+
+	return false
+}
 
-		printBasicTable(data)
+func withoutUser(users []vinyldns.User, id string) []vinyldns.User {
+	kept := []vinyldns.User{}
+	for _, u := range users {
+		if u.ID != id {
+			kept = append(kept, u)
+		}
 	}
+
+	return kept
 }
 
-func printGroup(group vinyldns.Group) {
-	data := [][]string{
-		{"Name", group.Name},
-		{"Status", group.Status},
-		{"Created", group.Created},
-		{"ID", group.ID},
+func userIDList(mems []vinyldns.User) string {
+	members := []string{}
+
+	for _, m := range mems {
+		members = append(members, m.ID)
 	}
 
-	printBasicTable(data)
+	return strings.Join(members, ", ")
+}
+
+var userFields = []field{
+	{"UserName", "UserName"},
+	{"FirstName", "FirstName"},
+	{"LastName", "LastName"},
+	{"ID", "ID"},
+	{"Email", "Email"},
+	{"Created", "Created"},
+}
+
+// groupUserFlags are the flags of the commands that change a group's members
+// or admins.
+var groupUserFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "group-id",
+		Usage: "The group ID",
+	},
+	cli.StringFlag{
+		Name:  "group-name",
+		Usage: "The group name (an alternative to group-id)",
+	},
+	cli.StringSliceFlag{
+		Name:     "user",
+		Usage:    "The user ID or username; repeat the flag for multiple users",
+		Required: true,
+	},
 }
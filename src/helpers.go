@@ -13,28 +13,133 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/olekukonko/tablewriter"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/urfave/cli"
 	"github.com/vinyldns/go-vinyldns/vinyldns"
+	"gopkg.in/yaml.v3"
 )
 
+// insecureWarning prints the --insecure-skip-verify warning once, however
+// many clients a command creates.
+var insecureWarning sync.Once
+
 func client(c *cli.Context) *vinyldns.Client {
-	validateEnv(c)
+	p, err := currentProfile(c)
+	if err != nil {
+		exitWithError(c, err)
+	}
+
+	exitWithError(c, validateEnv(c, p))
+	s, err := newTransportSettings(c, p)
+	if err != nil {
+		exitWithError(c, usageError(err))
+	}
+	transport, err := newHTTPTransport(s)
+	if err != nil {
+		exitWithError(c, err)
+	}
+
+	host := setting(c, p, hostFlag)
+	if s.tls.InsecureSkipVerify {
+		insecureWarning.Do(func() {
+			fmt.Fprintf(os.Stderr, "WARNING: --%s is set, so the TLS certificate of %s is NOT verified and anyone on the network path can read and change your requests, keys included. Use --%s instead.\n", insecureSkipVerifyFlag, host, caCertFlag)
+		})
+	}
+
 	return &vinyldns.Client{
-		AccessKey:  c.GlobalString(accessKeyFlag),
-		SecretKey:  c.GlobalString(secretKeyFlag),
-		Host:       c.GlobalString(hostFlag),
-		HTTPClient: &http.Client{},
+		AccessKey:  setting(c, p, accessKeyFlag),
+		SecretKey:  setting(c, p, secretKeyFlag),
+		Host:       host,
+		HTTPClient: &http.Client{Transport: transport},
 		UserAgent:  userAgent(),
 	}
 }
 
+// request sends a signed JSON request to the VinylDNS API and decodes the
+// response into out. It covers endpoints and payloads that go-vinyldns does
+// not model, and fails with a vinyldns.Error just like go-vinyldns does.
+func request(c *vinyldns.Client, method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = b
+	}
+
+	contents, err := rawRequest(c, method, path, body)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(contents) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(contents, out)
+}
+
+// rawRequest signs body the same way go-vinyldns does and returns the raw
+// response body.
+func rawRequest(c *vinyldns.Client, method, path string, body []byte) ([]byte, error) {
+	url := strings.TrimRight(c.Host, "/") + path
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Set("Content-Type", "application/json")
+
+	hash := sha256.Sum256(body)
+	creds := aws.Credentials{
+		AccessKeyID:     c.AccessKey,
+		SecretAccessKey: c.SecretKey,
+	}
+	err = v4.NewSigner().SignHTTP(context.Background(), creds, req, hex.EncodeToString(hash[:]), "VinylDNS", "us-east-1", time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &vinyldns.Error{
+			RequestURL:    url,
+			RequestMethod: method,
+			RequestBody:   string(body),
+			ResponseCode:  resp.StatusCode,
+			ResponseBody:  string(contents),
+		}
+	}
+
+	return contents, nil
+}
+
 func userAgent() string {
 	if version == "" {
 		return "vinyldns-cli"
@@ -50,57 +155,94 @@ func getOption(c *cli.Context, name string) (string, error) {
 	val := c.String(name)
 	var err error
 	if len(val) == 0 {
-		err = fmt.Errorf("--%s is required", name)
+		err = usageError(fmt.Errorf("--%s is required", name))
 	}
 	return val, err
 }
 
-func validateEnv(c *cli.Context) {
-	h := c.GlobalString(hostFlag)
-	ak := c.GlobalString(accessKeyFlag)
-	sk := c.GlobalString(secretKeyFlag)
-	missing := []string{}
-
-	if h == "" {
-		missing = append(missing, h)
-		fmt.Printf("\nPlease pass '--%s' or set 'VINYLDNS_HOST'\n", hostFlag)
+// payloadFlags are the flags of the commands that take a VinylDNS JSON
+// payload describing what.
+func payloadFlags(what string) []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "json",
+			Usage: fmt.Sprintf("The VinylDNS JSON or YAML representing the %s, '@<path>' to read it from a file, or '-' for stdin", what),
+		},
+		cli.StringFlag{
+			Name:  "file, f",
+			Usage: fmt.Sprintf("A JSON or YAML file representing the %s, or '-' for stdin", what),
+		},
 	}
-	if ak == "" {
-		missing = append(missing, h)
-		fmt.Printf("\nPlease pass '--%s' or set 'VINYLDNS_ACCESS_KEY'\n", accessKeyFlag)
+}
+
+// readPayload decodes the --json or --file payload into v. YAML is converted
+// to JSON first, so both decode into the same vinyldns structs.
+func readPayload(c *cli.Context, v interface{}) error {
+	source := c.String("json")
+	if f := c.String("file"); f != "" {
+		source = "@" + f
 	}
-	if sk == "" {
-		missing = append(missing, h)
-		fmt.Printf("\nPlease pass '--%s' or set 'VINYLDNS_SECRET_KEY'\n", secretKeyFlag)
+
+	data, err := readJSON(source)
+	if err != nil {
+		return err
 	}
 
-	if len(missing) > 0 {
-		os.Exit(1)
+	if err := json.Unmarshal(data, v); err != nil {
+		return invalidError(fmt.Errorf("invalid payload: %w", err))
 	}
-}
 
-func printBasicTable(data [][]string) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.AppendBulk(data)
-	table.SetRowLine(true)
-	table.Render()
+	return nil
 }
 
-func printTableWithHeaders(headers []string, data [][]string) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader(headers)
-	table.AppendBulk(data)
-	table.SetRowLine(true)
-	table.Render()
+// readJSON reads a JSON or YAML payload given inline, as '@<path>' to read
+// it from a file, or as '-' or '@-' for stdin, and returns it as JSON.
+func readJSON(source string) ([]byte, error) {
+	var data []byte
+	var err error
+	switch {
+	case source == "-" || source == "@-":
+		data, err = io.ReadAll(os.Stdin)
+	case strings.HasPrefix(source, "@"):
+		data, err = os.ReadFile(source[1:])
+	default:
+		data = []byte(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] != '{' && trimmed[0] != '[' {
+		var y interface{}
+		if err := yaml.Unmarshal(data, &y); err != nil {
+			return nil, invalidError(fmt.Errorf("the payload is neither JSON nor YAML: %w", err))
+		}
+		if data, err = json.Marshal(y); err != nil {
+			return nil, invalidError(fmt.Errorf("the YAML payload cannot be converted to JSON: %w", err))
+		}
+	}
+
+	return data, nil
 }
 
-func printJSON(i interface{}) error {
-	j, err := json.Marshal(i)
-	if err != nil {
-		return err
+// validateEnv checks that the host and keys are set, failing with a usage
+// error that says how to set each missing one.
+func validateEnv(c *cli.Context, p profile) error {
+	missing := []string{}
+	for _, s := range []struct{ flag, env string }{
+		{hostFlag, "VINYLDNS_HOST"},
+		{accessKeyFlag, "VINYLDNS_ACCESS_KEY"},
+		{secretKeyFlag, "VINYLDNS_SECRET_KEY"},
+	} {
+		if setting(c, p, s.flag) == "" {
+			missing = append(missing, fmt.Sprintf("Please pass '--%s', set '%s' or add it to a profile", s.flag, s.env))
+		}
 	}
 
-	fmt.Println(string(j))
+	if len(missing) > 0 {
+		return usageError(errors.New(strings.Join(missing, "\n")))
+	}
 
 	return nil
 }
@@ -0,0 +1,339 @@
+/*
+Copyright 2018 Comcast Cable Communications Management, LLC
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep is one element of a parsed JSONPath expression.
+type jsonPathStep struct {
+	text      string // the step as written, used in error messages
+	name      string // a field name, or "*" for every field or element
+	recursive bool   // ..name: match name at any depth
+	index     *int
+	slice     *[2]*int
+}
+
+// jsonPathSegment is literal text or, if steps is set, an expression.
+type jsonPathSegment struct {
+	text  string
+	steps []jsonPathStep
+}
+
+// parseJSONPathTemplate parses kubectl-style JSONPath such as
+// "{.recordSets[*].id}". Text outside of braces is printed as is; an
+// expression without any braces is treated as if it were wrapped in them.
+func parseJSONPathTemplate(tmpl string) ([]jsonPathSegment, error) {
+	if !strings.Contains(tmpl, "{") {
+		tmpl = "{" + tmpl + "}"
+	}
+
+	segments := []jsonPathSegment{}
+	for tmpl != "" {
+		open := strings.Index(tmpl, "{")
+		if open < 0 {
+			segments = append(segments, jsonPathSegment{text: tmpl})
+			break
+		}
+		if open > 0 {
+			segments = append(segments, jsonPathSegment{text: tmpl[:open]})
+		}
+
+		end := strings.Index(tmpl[open:], "}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed '{' in %q", tmpl)
+		}
+		expr := tmpl[open+1 : open+end]
+		steps, err := parseJSONPath(expr)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, jsonPathSegment{text: expr, steps: steps})
+		tmpl = tmpl[open+end+1:]
+	}
+
+	return segments, nil
+}
+
+func parseJSONPath(expr string) ([]jsonPathStep, error) {
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+	steps := []jsonPathStep{}
+
+	for s != "" {
+		switch {
+		case strings.HasPrefix(s, ".."):
+			name, rest := jsonPathName(s[2:])
+			if name == "" {
+				return nil, fmt.Errorf("expected a field name after '..' in %q", expr)
+			}
+			steps = append(steps, jsonPathStep{text: ".." + name, name: name, recursive: true})
+			s = rest
+		case s[0] == '.':
+			name, rest := jsonPathName(s[1:])
+			if name == "" {
+				// a lone "." selects the current value
+				s = rest
+				continue
+			}
+			steps = append(steps, jsonPathStep{text: "." + name, name: name})
+			s = rest
+		case s[0] == '[':
+			end := strings.Index(s, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed '[' in %q", expr)
+			}
+			step, err := parseJSONPathSubscript(s[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("%v in %q", err, expr)
+			}
+			steps = append(steps, step)
+			s = s[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in %q", s[0], expr)
+		}
+	}
+
+	return steps, nil
+}
+
+func jsonPathName(s string) (string, string) {
+	if strings.HasPrefix(s, "*") {
+		return "*", s[1:]
+	}
+
+	i := 0
+	for i < len(s) && s[i] != '.' && s[i] != '[' {
+		i++
+	}
+
+	return s[:i], s[i:]
+}
+
+func parseJSONPathSubscript(text string) (jsonPathStep, error) {
+	step := jsonPathStep{text: text}
+	inner := strings.TrimSpace(text[1 : len(text)-1])
+
+	switch {
+	case inner == "*":
+		step.name = "*"
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+		step.name = inner[1 : len(inner)-1]
+	case strings.Contains(inner, ":"):
+		parts := strings.SplitN(inner, ":", 2)
+		var bounds [2]*int
+		for i, p := range parts {
+			if p = strings.TrimSpace(p); p == "" {
+				continue
+			}
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return step, fmt.Errorf("invalid slice %s", text)
+			}
+			bounds[i] = &n
+		}
+		step.slice = &bounds
+	default:
+		n, err := strconv.Atoi(inner)
+		if err != nil {
+			return step, fmt.Errorf("invalid subscript %s", text)
+		}
+		step.index = &n
+	}
+
+	return step, nil
+}
+
+// evalJSONPath renders segments against i, which is converted to its JSON
+// form first so paths use the same keys as --output json.
+func evalJSONPath(segments []jsonPathSegment, i interface{}) (string, error) {
+	j, err := json.Marshal(i)
+	if err != nil {
+		return "", err
+	}
+	var data interface{}
+	if err := json.Unmarshal(j, &data); err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	for _, seg := range segments {
+		if seg.steps == nil {
+			out.WriteString(seg.text)
+			continue
+		}
+
+		results := []interface{}{data}
+		path := ""
+		for _, step := range seg.steps {
+			path += step.text
+			results, err = step.apply(results)
+			if err != nil {
+				return "", fmt.Errorf("%s: %v", path, err)
+			}
+		}
+
+		values := []string{}
+		for _, r := range results {
+			values = append(values, jsonPathValue(r))
+		}
+		out.WriteString(strings.Join(values, " "))
+	}
+
+	return out.String(), nil
+}
+
+func (step jsonPathStep) apply(values []interface{}) ([]interface{}, error) {
+	results := []interface{}{}
+	for _, v := range values {
+		switch {
+		case step.recursive:
+			results = append(results, descendants(v, step.name)...)
+		case step.index != nil || step.slice != nil:
+			list, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot index %s", jsonType(v))
+			}
+			start, end, err := step.bounds(len(list))
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, list[start:end]...)
+		case step.name == "*":
+			switch x := v.(type) {
+			case []interface{}:
+				results = append(results, x...)
+			case map[string]interface{}:
+				for _, k := range sortedKeys(x) {
+					results = append(results, x[k])
+				}
+			}
+		default:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot select field %q from %s", step.name, jsonType(v))
+			}
+			child, ok := m[step.name]
+			if !ok {
+				return nil, fmt.Errorf("field %q is not found", step.name)
+			}
+			results = append(results, child)
+		}
+	}
+
+	return results, nil
+}
+
+// bounds resolves an index or slice step against a list of length n.
+func (step jsonPathStep) bounds(n int) (int, int, error) {
+	resolve := func(i int) int {
+		if i < 0 {
+			return i + n
+		}
+		return i
+	}
+
+	if step.index != nil {
+		i := resolve(*step.index)
+		if i < 0 || i >= n {
+			return 0, 0, fmt.Errorf("index %d is out of range (length %d)", *step.index, n)
+		}
+		return i, i + 1, nil
+	}
+
+	start, end := 0, n
+	if step.slice[0] != nil {
+		start = resolve(*step.slice[0])
+	}
+	if step.slice[1] != nil {
+		end = resolve(*step.slice[1])
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > n {
+		end = n
+	}
+	if start > end {
+		start = end
+	}
+
+	return start, end, nil
+}
+
+func descendants(v interface{}, name string) []interface{} {
+	results := []interface{}{}
+	switch x := v.(type) {
+	case map[string]interface{}:
+		if child, ok := x[name]; ok {
+			results = append(results, child)
+		}
+		for _, k := range sortedKeys(x) {
+			results = append(results, descendants(x[k], name)...)
+		}
+	case []interface{}:
+		for _, child := range x {
+			results = append(results, descendants(child, name)...)
+		}
+	}
+
+	return results
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	}
+
+	return "null"
+}
+
+func jsonPathValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	}
+
+	j, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(j)
+}
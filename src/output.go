@@ -0,0 +1,536 @@
+/*
+Copyright 2018 Comcast Cable Communications Management, LLC
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli"
+	"github.com/vinyldns/go-vinyldns/vinyldns"
+	"gopkg.in/yaml.v3"
+)
+
+// the values accepted by --output
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+	yamlOutput  = "yaml"
+	csvOutput   = "csv"
+	tsvOutput   = "tsv"
+
+	// these take an argument, e.g. --output 'jsonpath={.name}'
+	templateOutput = "template"
+	jsonPathOutput = "jsonpath"
+)
+
+var outputFormats = []string{tableOutput, jsonOutput, yamlOutput, csvOutput, tsvOutput, templateOutput + "=<go-template>", jsonPathOutput + "=<expression>"}
+
+// field is one value shown in table, csv and tsv output: header labels it and
+// path is the dotted Go field path to it, e.g. "Zone.Name".
+type field struct {
+	header string
+	path   string
+}
+
+// fields returns fields whose headers are their paths.
+func fields(paths ...string) []field {
+	fs := []field{}
+	for _, p := range paths {
+		fs = append(fs, field{header: p, path: p})
+	}
+
+	return fs
+}
+
+func validateOutput(c *cli.Context) error {
+	o := c.GlobalString(outputFlag)
+	if o == "" {
+		return nil
+	}
+
+	switch format, arg := splitOutput(o); format {
+	case tableOutput, jsonOutput, yamlOutput, csvOutput, tsvOutput:
+		if arg == "" {
+			return nil
+		}
+	case templateOutput:
+		_, err := parseOutputTemplate(arg)
+		return err
+	case jsonPathOutput:
+		if _, err := parseJSONPathTemplate(arg); err != nil {
+			return fmt.Errorf("invalid --%s jsonpath: %v", outputFlag, err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown --%s %s; must be one of: %s", outputFlag, o, strings.Join(outputFormats, ", "))
+}
+
+func outputFormat(c *cli.Context) string {
+	if o := c.GlobalString(outputFlag); o != "" {
+		format, _ := splitOutput(o)
+		return format
+	}
+
+	return tableOutput
+}
+
+// outputArg returns the template or expression given with the output format.
+func outputArg(c *cli.Context) string {
+	_, arg := splitOutput(c.GlobalString(outputFlag))
+	return arg
+}
+
+func splitOutput(o string) (string, string) {
+	if i := strings.Index(o, "="); i >= 0 {
+		return o[:i], o[i+1:]
+	}
+
+	return o, ""
+}
+
+func parseOutputTemplate(text string) (*template.Template, error) {
+	t, err := template.New("output").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s template: %v", outputFlag, err)
+	}
+
+	return t, nil
+}
+
+// printStructured prints i as JSON or YAML, or through a template or
+// JSONPath expression, reporting whether the output format was one of those.
+func printStructured(c *cli.Context, i interface{}) (bool, error) {
+	i = redact(c, i)
+	switch outputFormat(c) {
+	case jsonOutput:
+		return true, printJSON(i)
+	case yamlOutput:
+		return true, printYAML(i)
+	case templateOutput:
+		return true, printTemplate(outputArg(c), i)
+	case jsonPathOutput:
+		return true, printJSONPath(outputArg(c), i)
+	}
+
+	return false, nil
+}
+
+// printList prints a slice of items, one row per item. Tables show fs, or
+// wide with --wide, unless --columns picks fields of the items instead.
+func printList(c *cli.Context, items interface{}, fs, wide []field, noun string) error {
+	v := reflect.ValueOf(items)
+	fs, err := listFields(c, v.Type().Elem(), fs, wide)
+	if err != nil {
+		return err
+	}
+
+	v, err = sortItems(c, v)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := printStructured(c, v.Interface()); ok {
+		return err
+	}
+
+	v = reflect.ValueOf(redact(c, v.Interface()))
+	rows := [][]string{}
+	for i := 0; i < v.Len(); i++ {
+		rows = append(rows, fieldValues(v.Index(i).Interface(), fs))
+	}
+
+	if len(rows) == 0 && outputFormat(c) == tableOutput {
+		fmt.Printf("No %s found\n", noun)
+		return nil
+	}
+
+	return printRows(c, headers(fs), rows)
+}
+
+// listFields returns the fields to show for a list of t.
+func listFields(c *cli.Context, t reflect.Type, fs, wide []field) ([]field, error) {
+	if cols := c.GlobalString(columnsFlag); cols != "" {
+		selected := []field{}
+		for _, col := range strings.Split(cols, ",") {
+			col = strings.TrimSpace(col)
+			if !hasPath(t, col) {
+				return nil, unknownField(columnsFlag, col, t)
+			}
+			selected = append(selected, field{header: col, path: col})
+		}
+		return selected, nil
+	}
+
+	if c.GlobalBool(wideFlag) && wide != nil {
+		return wide, nil
+	}
+
+	return fs, nil
+}
+
+// sortItems returns a sorted copy of the slice v when --sort-by is passed. A
+// leading "-" sorts in descending order.
+func sortItems(c *cli.Context, v reflect.Value) (reflect.Value, error) {
+	path := c.GlobalString(sortByFlag)
+	if path == "" {
+		return v, nil
+	}
+
+	desc := strings.HasPrefix(path, "-")
+	path = strings.TrimPrefix(path, "-")
+	if !hasPath(v.Type().Elem(), path) {
+		return v, unknownField(sortByFlag, path, v.Type().Elem())
+	}
+
+	sorted := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+	reflect.Copy(sorted, v)
+	sort.SliceStable(sorted.Interface(), func(i, j int) bool {
+		a := fieldByPath(sorted.Index(i), path)
+		b := fieldByPath(sorted.Index(j), path)
+		if desc {
+			return compareValues(b, a) < 0
+		}
+		return compareValues(a, b) < 0
+	})
+
+	return sorted, nil
+}
+
+// compareValues orders numbers numerically and everything else by how it is
+// displayed; timestamps are RFC 3339 strings, so they sort chronologically.
+func compareValues(a, b reflect.Value) int {
+	for _, v := range []*reflect.Value{&a, &b} {
+		for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+			*v = v.Elem()
+		}
+	}
+
+	if isInt(a) && isInt(b) {
+		switch {
+		case a.Int() < b.Int():
+			return -1
+		case a.Int() > b.Int():
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(formatValue(a), formatValue(b))
+}
+
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+
+	return false
+}
+
+// hasPath reports whether fieldByPath can follow path through values of t.
+func hasPath(t reflect.Type, path string) bool {
+	for _, name := range strings.Split(path, ".") {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+
+		f, ok := t.FieldByNameFunc(func(n string) bool {
+			return strings.EqualFold(n, name)
+		})
+		if !ok || !f.IsExported() {
+			return false
+		}
+		t = f.Type
+	}
+
+	return true
+}
+
+func unknownField(flag, path string, t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	names := []string{}
+	if t.Kind() == reflect.Struct {
+		for _, f := range reflect.VisibleFields(t) {
+			if f.IsExported() && !f.Anonymous {
+				names = append(names, f.Name)
+			}
+		}
+	}
+
+	return usageError(fmt.Errorf("unknown --%s field %s; available fields: %s", flag, path, strings.Join(names, ", ")))
+}
+
+// printItem prints a single item; tables show one field per row.
+func printItem(c *cli.Context, item interface{}, fs []field) error {
+	if ok, err := printStructured(c, item); ok {
+		return err
+	}
+
+	values := fieldValues(redact(c, item), fs)
+	if outputFormat(c) != tableOutput {
+		return printRows(c, headers(fs), [][]string{values})
+	}
+
+	data := [][]string{}
+	for i, f := range fs {
+		data = append(data, []string{f.header, values[i]})
+	}
+
+	printBasicTable(data)
+
+	return nil
+}
+
+// printResult prints the API response to a change as JSON or YAML, or a
+// short confirmation message otherwise.
+func printResult(c *cli.Context, result interface{}, message string) error {
+	if ok, err := printStructured(c, result); ok {
+		return err
+	}
+
+	fmt.Println(message)
+
+	return nil
+}
+
+// printRows prints rows under headers, which --no-headers leaves out.
+func printRows(c *cli.Context, headers []string, rows [][]string) error {
+	if c.GlobalBool(noHeadersFlag) {
+		headers = nil
+	}
+
+	switch outputFormat(c) {
+	case csvOutput:
+		return printDelimited(os.Stdout, ',', headers, rows)
+	case tsvOutput:
+		return printDelimited(os.Stdout, '\t', headers, rows)
+	}
+
+	printTableWithHeaders(headers, rows)
+
+	return nil
+}
+
+func printDelimited(out io.Writer, delim rune, headers []string, rows [][]string) error {
+	w := csv.NewWriter(out)
+	w.Comma = delim
+	if headers != nil {
+		if err := w.Write(headers); err != nil {
+			return err
+		}
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return w.Error()
+}
+
+func headers(fs []field) []string {
+	hs := []string{}
+	for _, f := range fs {
+		hs = append(hs, f.header)
+	}
+
+	return hs
+}
+
+func fieldValues(item interface{}, fs []field) []string {
+	values := []string{}
+	for _, f := range fs {
+		values = append(values, formatValue(fieldByPath(reflect.ValueOf(item), f.path)))
+	}
+
+	return values
+}
+
+// fieldByPath follows a dotted, case-insensitive Go field path through
+// structs and pointers. It returns the zero Value if the path does not exist.
+func fieldByPath(v reflect.Value, path string) reflect.Value {
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}
+		}
+
+		v = v.FieldByNameFunc(func(n string) bool {
+			return strings.EqualFold(n, name)
+		})
+		if !v.IsValid() {
+			return v
+		}
+	}
+
+	return v
+}
+
+func formatValue(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+
+	switch x := v.Interface().(type) {
+	case []vinyldns.Record:
+		return getRecord(x)
+	case []record:
+		return getRecord(fromRecords(x))
+	case []vinyldns.User:
+		return userIDList(x)
+	case []vinyldns.RecordChange:
+		return changeList(x)
+	case []string:
+		return strings.Join(x, ", ")
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return ""
+		}
+		return formatValue(v.Elem())
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	}
+
+	j, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Sprintf("%v", v.Interface())
+	}
+
+	return string(j)
+}
+
+func printBasicTable(data [][]string) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.AppendBulk(data)
+	table.SetRowLine(true)
+	table.Render()
+}
+
+func printTableWithHeaders(headers []string, data [][]string) {
+	table := tablewriter.NewWriter(os.Stdout)
+	if headers != nil {
+		table.SetHeader(headers)
+	}
+	table.AppendBulk(data)
+	table.SetRowLine(true)
+	table.Render()
+}
+
+func printJSON(i interface{}) error {
+	j, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(j))
+
+	return nil
+}
+
+// printTemplate executes a Go template against i. Fields are the Go field
+// names of the same structs printJSON marshals, e.g. {{.Zone.Name}}.
+func printTemplate(text string, i interface{}) error {
+	t, err := parseOutputTemplate(text)
+	if err != nil {
+		return err
+	}
+
+	// text/template names the field path that failed, e.g.
+	// `executing "output" at <.Zone.Nmae>: can't evaluate field Nmae`
+	var out strings.Builder
+	if err := t.Execute(&out, i); err != nil {
+		return fmt.Errorf("--%s template: %v", outputFlag, err)
+	}
+
+	fmt.Print(out.String())
+	if !strings.HasSuffix(out.String(), "\n") {
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// printJSONPath prints the values a JSONPath expression selects from the
+// JSON form of i, e.g. {.zone.name}.
+func printJSONPath(expr string, i interface{}) error {
+	segments, err := parseJSONPathTemplate(expr)
+	if err != nil {
+		return fmt.Errorf("invalid --%s jsonpath: %v", outputFlag, err)
+	}
+
+	out, err := evalJSONPath(segments, i)
+	if err != nil {
+		return fmt.Errorf("--%s jsonpath %s: %v", outputFlag, expr, err)
+	}
+	fmt.Println(out)
+
+	return nil
+}
+
+// printYAML prints i with the same keys, in the same order, as printJSON.
+func printYAML(i interface{}) error {
+	j, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(j, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// blockStyle drops the flow style and quoting that parsing JSON leaves on
+// YAML nodes, so they print as conventional block YAML.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, child := range n.Content {
+		blockStyle(child)
+	}
+}
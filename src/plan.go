@@ -0,0 +1,477 @@
+/*
+Copyright 2018 Comcast Cable Communications Management, LLC
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+	"github.com/vinyldns/go-vinyldns/vinyldns"
+	"gopkg.in/yaml.v3"
+)
+
+// manifest is the desired state of the record sets of one or more zones.
+type manifest struct {
+	Zones []manifestZone `yaml:"zones"`
+}
+
+type manifestZone struct {
+	Name       string              `yaml:"name"`
+	ID         string              `yaml:"id"`
+	RecordSets []manifestRecordSet `yaml:"recordSets"`
+}
+
+// manifestRecordSet is a record set whose records use the same syntax as
+// --record-set-data.
+type manifestRecordSet struct {
+	Name           string   `yaml:"name"`
+	Type           string   `yaml:"type"`
+	TTL            int      `yaml:"ttl"`
+	Records        []string `yaml:"records"`
+	OwnerGroupID   string   `yaml:"ownerGroupId"`
+	OwnerGroupName string   `yaml:"ownerGroupName"`
+}
+
+// the actions of a planChange
+const (
+	createAction = "Create"
+	updateAction = "Update"
+	deleteAction = "Delete"
+)
+
+// planChange is a record set whose current state differs from the manifest.
+type planChange struct {
+	Action  string            `json:"action"`
+	Zone    string            `json:"zone"`
+	Name    string            `json:"name"`
+	Type    string            `json:"type"`
+	Diff    string            `json:"diff"`
+	Current *recordSetPayload `json:"current,omitempty"`
+	Desired *recordSetPayload `json:"desired,omitempty"`
+}
+
+// applyResult is the outcome of applying a planChange.
+type applyResult struct {
+	Action   string `json:"action"`
+	Zone     string `json:"zone"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Status   string `json:"status"`
+	ChangeID string `json:"changeId"`
+}
+
+var (
+	planFields        = fields("Action", "Zone", "Name", "Type", "Diff")
+	applyResultFields = fields("Action", "Zone", "Name", "Type", "Status", "ChangeID")
+)
+
+func plan(c *cli.Context) error {
+	m, err := readManifest(c.String("file"))
+	if err != nil {
+		return err
+	}
+
+	changes, err := makePlan(client(c), m, c.Bool("prune"))
+	if err != nil {
+		return err
+	}
+
+	return printPlan(c, changes)
+}
+
+func apply(c *cli.Context) error {
+	path := c.String("file")
+	if path == "-" && !c.Bool("yes") {
+		return usageError(errors.New("--yes is required when the manifest is read from stdin"))
+	}
+
+	m, err := readManifest(path)
+	if err != nil {
+		return err
+	}
+
+	client := client(c)
+	changes, err := makePlan(client, m, c.Bool("prune"))
+	if err != nil {
+		return err
+	}
+
+	// JSON and YAML output is kept to the results, so the plan is only
+	// shown in tables
+	if outputFormat(c) == tableOutput || len(changes) == 0 {
+		if err := printPlan(c, changes); err != nil || len(changes) == 0 {
+			return err
+		}
+	}
+
+	if !c.Bool("yes") {
+		ok, err := confirm("Apply these changes?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("Apply cancelled")
+		}
+	}
+
+	if c.Bool("batch") {
+		return applyBatch(c, client, changes)
+	}
+
+	results := []applyResult{}
+	for _, ch := range changes {
+		result, err := applyChange(c, client, ch)
+		if err != nil {
+			printList(c, results, applyResultFields, nil, "changes")
+			return fmt.Errorf("%s %s %s in zone %s failed: %w", ch.Action, ch.Name, ch.Type, ch.Zone, err)
+		}
+		results = append(results, result)
+	}
+
+	return printList(c, results, applyResultFields, nil, "changes")
+}
+
+func printPlan(c *cli.Context, changes []planChange) error {
+	if len(changes) == 0 {
+		return printResult(c, changes, "No changes; the zones match the manifest")
+	}
+
+	if err := printList(c, changes, planFields, nil, "changes"); err != nil {
+		return err
+	}
+
+	if outputFormat(c) == tableOutput {
+		counts := map[string]int{}
+		for _, ch := range changes {
+			counts[ch.Action]++
+		}
+		fmt.Printf("Plan: %d to create, %d to update, %d to delete\n", counts[createAction], counts[updateAction], counts[deleteAction])
+	}
+
+	return nil
+}
+
+func readManifest(path string) (*manifest, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	m := &manifest{}
+	dec := yaml.NewDecoder(in)
+	dec.KnownFields(true)
+	if err := dec.Decode(m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, invalidError(fmt.Errorf("invalid manifest %s: %w", path, err))
+	}
+	if len(m.Zones) == 0 {
+		return nil, invalidError(fmt.Errorf("manifest %s has no zones", path))
+	}
+
+	return m, nil
+}
+
+// makePlan compares the manifest's record sets with the zones' current ones.
+// Record sets missing from the manifest are only deleted when prune is set,
+// and the zones' SOA and apex NS record sets never are.
+func makePlan(client *vinyldns.Client, m *manifest, prune bool) ([]planChange, error) {
+	groups := map[string]string{}
+	groupID := func(id, name string) (string, error) {
+		if id != "" || name == "" {
+			return id, nil
+		}
+		if _, ok := groups[name]; !ok {
+			id, err := getGroupID(client, "", name)
+			if err != nil {
+				return "", err
+			}
+			groups[name] = id
+		}
+		return groups[name], nil
+	}
+
+	changes := []planChange{}
+	for _, mz := range m.Zones {
+		z, err := getZone(client, mz.Name, mz.ID)
+		if err != nil {
+			return nil, err
+		}
+		current, err := listRecordSets(client, z.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		existing := map[string]recordSetPayload{}
+		for _, rs := range current {
+			existing[recordSetKey(rs.RecordSet, z.Name)] = rs
+		}
+
+		seen := map[string]bool{}
+		for _, mrs := range mz.RecordSets {
+			desired, err := desiredRecordSet(z, mrs)
+			if err != nil {
+				return nil, fmt.Errorf("zone %s, record set %s %s: %w", z.Name, mrs.Name, mrs.Type, err)
+			}
+			if desired.OwnerGroupID, err = groupID(mrs.OwnerGroupID, mrs.OwnerGroupName); err != nil {
+				return nil, err
+			}
+
+			key := recordSetKey(desired.RecordSet, z.Name)
+			if seen[key] {
+				return nil, invalidError(fmt.Errorf("zone %s: record set %s %s is listed more than once", z.Name, mrs.Name, desired.Type))
+			}
+			seen[key] = true
+
+			ch := planChange{Zone: z.Name, Name: relativeName(desired.Name, z.Name), Type: desired.Type, Desired: &desired}
+			cur, ok := existing[key]
+			switch {
+			case !ok:
+				ch.Action = createAction
+				ch.Diff = describeRecordSet(desired)
+			default:
+				ch.Diff = recordSetDiff(cur, desired)
+				if ch.Diff == "" {
+					continue
+				}
+				ch.Action = updateAction
+				ch.Current = &cur
+				desired.ID = cur.ID
+				if desired.OwnerGroupID == "" {
+					desired.OwnerGroupID = cur.OwnerGroupID
+				}
+			}
+			changes = append(changes, ch)
+		}
+
+		if !prune {
+			continue
+		}
+		for _, rs := range current {
+			rs := rs
+			name := relativeName(rs.Name, z.Name)
+			if seen[recordSetKey(rs.RecordSet, z.Name)] || rs.Type == "SOA" || (rs.Type == "NS" && name == "@") {
+				continue
+			}
+			changes = append(changes, planChange{
+				Action:  deleteAction,
+				Zone:    z.Name,
+				Name:    name,
+				Type:    rs.Type,
+				Diff:    describeRecordSet(rs),
+				Current: &rs,
+			})
+		}
+	}
+
+	return changes, nil
+}
+
+func desiredRecordSet(z vinyldns.Zone, mrs manifestRecordSet) (recordSetPayload, error) {
+	t := typeSwitch(strings.ToUpper(mrs.Type))
+	if t == "" {
+		return recordSetPayload{}, invalidError(fmt.Errorf("unknown type %q", mrs.Type))
+	}
+	if mrs.TTL <= 0 {
+		return recordSetPayload{}, invalidError(errors.New("ttl is required"))
+	}
+	if len(mrs.Records) == 0 {
+		return recordSetPayload{}, invalidError(errors.New("records are required"))
+	}
+
+	records, err := parseRecords(t, mrs.Records)
+	if err != nil {
+		return recordSetPayload{}, err
+	}
+
+	name := relativeName(mrs.Name, z.Name)
+	if name == "@" {
+		name = z.Name
+	}
+
+	return recordSetPayload{
+		RecordSet: vinyldns.RecordSet{
+			ZoneID: z.ID,
+			Name:   name,
+			Type:   t,
+			TTL:    mrs.TTL,
+		},
+		Records: records,
+	}, nil
+}
+
+func recordSetKey(rs vinyldns.RecordSet, zoneName string) string {
+	return strings.ToLower(relativeName(rs.Name, zoneName)) + " " + rs.Type
+}
+
+// rdata returns the record set's records in zone file syntax, sorted.
+func rdata(rs recordSetPayload) []string {
+	data := []string{}
+	for _, r := range rs.Records {
+		d, _ := formatRecordData(rs.Type, r)
+		data = append(data, d)
+	}
+	sort.Strings(data)
+
+	return data
+}
+
+func describeRecordSet(rs recordSetPayload) string {
+	return fmt.Sprintf("ttl %d; %s", rs.TTL, strings.Join(rdata(rs), ", "))
+}
+
+// recordSetDiff describes how desired differs from cur, or returns "".
+func recordSetDiff(cur, desired recordSetPayload) string {
+	diffs := []string{}
+	if cur.TTL != desired.TTL {
+		diffs = append(diffs, fmt.Sprintf("ttl %d -> %d", cur.TTL, desired.TTL))
+	}
+
+	have, want := map[string]bool{}, map[string]bool{}
+	for _, d := range rdata(cur) {
+		have[d] = true
+	}
+	for _, d := range rdata(desired) {
+		want[d] = true
+		if !have[d] {
+			diffs = append(diffs, "+ "+d)
+		}
+	}
+	for _, d := range rdata(cur) {
+		if !want[d] {
+			diffs = append(diffs, "- "+d)
+		}
+	}
+
+	if desired.OwnerGroupID != "" && desired.OwnerGroupID != cur.OwnerGroupID {
+		diffs = append(diffs, fmt.Sprintf("owner group %s -> %s", cur.OwnerGroupID, desired.OwnerGroupID))
+	}
+
+	return strings.Join(diffs, "; ")
+}
+
+func applyChange(c *cli.Context, client *vinyldns.Client, ch planChange) (applyResult, error) {
+	result := applyResult{Action: ch.Action, Zone: ch.Zone, Name: ch.Name, Type: ch.Type}
+
+	var zoneID, recordSetID string
+	switch ch.Action {
+	case deleteAction:
+		resp, err := client.RecordSetDelete(ch.Current.ZoneID, ch.Current.ID)
+		if err != nil {
+			return result, err
+		}
+		zoneID, recordSetID = resp.Zone.ID, ch.Current.ID
+		result.Status, result.ChangeID = resp.Status, resp.ChangeID
+	default:
+		resp, err := submitRecordSet(client, ch.Desired.RecordSet, ch.Desired.Records)
+		if err != nil {
+			return result, err
+		}
+		zoneID, recordSetID = ch.Desired.ZoneID, resp.RecordSet.ID
+		result.Status, result.ChangeID = resp.Status, resp.ChangeID
+	}
+
+	if c.Bool("wait") {
+		done, err := waitForRecordSetChange(c, client, zoneID, recordSetID, result.ChangeID)
+		if err != nil {
+			return result, err
+		}
+		result.Status = done.Status
+	}
+
+	return result, nil
+}
+
+// applyBatch submits the plan as one batch change; updates replace the
+// record set's records.
+func applyBatch(c *cli.Context, client *vinyldns.Client, changes []planChange) error {
+	bc := batchChangePayload{BatchRecordChange: vinyldns.BatchRecordChange{Comments: c.String("comments")}}
+	for _, ch := range changes {
+		if !batchChangeTypes[ch.Type] {
+			return usageError(fmt.Errorf("batch changes do not support %s records; apply without --batch", ch.Type))
+		}
+
+		inputName := qualifyName(ch.Name, fqdn(ch.Zone))
+		if ip, ok := reverseAddress(inputName); ok && ch.Type == "PTR" {
+			inputName = ip
+		}
+
+		if ch.Action != createAction {
+			bc.Changes = append(bc.Changes, recordChange{RecordChange: vinyldns.RecordChange{
+				ChangeType: "DeleteRecordSet",
+				InputName:  inputName,
+				Type:       ch.Type,
+			}})
+		}
+		if ch.Action == deleteAction {
+			continue
+		}
+
+		if og := ch.Desired.OwnerGroupID; og != "" {
+			if bc.OwnerGroupID != "" && bc.OwnerGroupID != og {
+				return usageError(errors.New("a batch change has one owner group; apply record sets with different owner groups without --batch"))
+			}
+			bc.OwnerGroupID = og
+		}
+		for _, r := range ch.Desired.Records {
+			r := r
+			bc.Changes = append(bc.Changes, recordChange{
+				RecordChange: vinyldns.RecordChange{
+					ChangeType: "Add",
+					InputName:  inputName,
+					Type:       ch.Type,
+					TTL:        ch.Desired.TTL,
+				},
+				Record: &r,
+			})
+		}
+	}
+
+	created, err := submitBatchChange(client, bc)
+	if err != nil {
+		return err
+	}
+
+	var result interface{} = created
+	if c.Bool("wait") {
+		done, err := waitForBatchChange(c, client, created.ID)
+		if err != nil {
+			return err
+		}
+		result = done.batchChange()
+	}
+
+	return printResult(c, result, fmt.Sprintf("Submitted batch change %s with %d changes", created.ID, len(bc.Changes)))
+}
+
+// confirm asks a yes or no question on stderr and reads the answer from stdin.
+func confirm(question string) (bool, error) {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes", nil
+}
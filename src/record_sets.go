@@ -18,11 +18,24 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/crackcomm/go-clitable"
 	"github.com/urfave/cli"
 	"github.com/vinyldns/go-vinyldns/vinyldns"
 )
 
+var recordSetChangeFields = []field{
+	{"Zone", "Zone.Name"},
+	{"RecordSetName", "RecordSet.Name"},
+	{"RecordSetID", "RecordSet.ID"},
+	{"UserID", "UserID"},
+	{"ChangeType", "ChangeType"},
+	{"Status", "Status"},
+	{"Created", "Created"},
+	{"ID", "ID"},
+}
+
+// recordSetWideFields are the record set columns shown with --wide.
+var recordSetWideFields = fields("Name", "ID", "Type", "TTL", "Records", "Status", "OwnerGroupID", "Updated")
+
 func recordSetChanges(c *cli.Context) error {
 	client := client(c)
 	zh, err := client.RecordSetChanges(c.String("zone-id"), vinyldns.ListFilterRecordSetChanges{})
@@ -31,24 +44,7 @@ func recordSetChanges(c *cli.Context) error {
 	}
 	rsc := zh.RecordSetChanges
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(rsc)
-	}
-
-	for _, c := range rsc {
-		clitable.PrintHorizontal(map[string]interface{}{
-			"Zone":          c.Zone.Name,
-			"RecordSetName": c.RecordSet.Name,
-			"RecordSetID":   c.RecordSet.ID,
-			"UserID":        c.UserID,
-			"ChangeType":    c.ChangeType,
-			"Status":        c.Status,
-			"Created":       c.Created,
-			"ID":            c.ID,
-		})
-	}
-
-	return nil
+	return printList(c, rsc, recordSetChangeFields, nil, "record set changes")
 }
 
 func recordSetChange(c *cli.Context) error {
@@ -58,22 +54,7 @@ func recordSetChange(c *cli.Context) error {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(rsc)
-	}
-
-	clitable.PrintHorizontal(map[string]interface{}{
-		"Zone":          rsc.Zone.Name,
-		"RecordSetName": rsc.RecordSet.Name,
-		"RecordSetID":   rsc.RecordSet.ID,
-		"UserID":        rsc.UserID,
-		"ChangeType":    rsc.ChangeType,
-		"Status":        rsc.Status,
-		"Created":       rsc.Created,
-		"ID":            rsc.ID,
-	})
-
-	return nil
+	return printItem(c, rsc, recordSetChangeFields)
 }
 
 func recordSets(c *cli.Context) error {
@@ -83,27 +64,7 @@ func recordSets(c *cli.Context) error {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(rs)
-	}
-
-	s := []map[string]interface{}{}
-	for _, r := range rs {
-		m := map[string]interface{}{}
-		m["Name"] = r.Name
-		m["ID"] = r.ID
-		m["Type"] = r.Type
-		m["Status"] = r.Status
-		s = append(s, m)
-	}
-
-	if len(s) != 0 {
-		clitable.PrintTable([]string{"Name", "ID", "Type", "Status"}, s)
-	} else {
-		fmt.Printf("No record sets found")
-	}
-
-	return nil
+	return printList(c, rs, fields("Name", "ID", "Type", "Status"), recordSetWideFields, "record sets")
 }
 
 func searchRecordSets(c *cli.Context) error {
@@ -154,27 +115,7 @@ func searchRecordSets(c *cli.Context) error {
 		}
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(rs)
-	}
-
-	s := []map[string]interface{}{}
-	for _, r := range rs {
-		m := map[string]interface{}{}
-		m["Name"] = r.Name
-		m["ID"] = r.ID
-		m["Type"] = r.Type
-		m["Status"] = r.Status
-		s = append(s, m)
-	}
-
-	if len(s) != 0 {
-		clitable.PrintTable([]string{"Name", "ID", "Type", "Status"}, s)
-	} else {
-		fmt.Printf("No record sets found")
-	}
-
-	return nil
+	return printList(c, rs, fields("Name", "ID", "Type", "Status"), recordSetWideFields, "record sets")
 }
 
 func recordSet(c *cli.Context) error {
@@ -184,24 +125,18 @@ func recordSet(c *cli.Context) error {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(rs)
-	}
-
-	clitable.PrintHorizontal(map[string]interface{}{
-		"Zone":    rs.ZoneID,
-		"Name":    rs.Name,
-		"Account": rs.Account,
-		"ID":      rs.ID,
-		"Type":    rs.Type,
-		"Records": getRecord(rs.Records),
-		"Created": rs.Created,
-		"Status":  rs.Status,
-		"Updated": rs.Updated,
-		"TTL":     rs.TTL,
+	return printItem(c, rs, []field{
+		{"Zone", "ZoneID"},
+		{"Name", "Name"},
+		{"Account", "Account"},
+		{"ID", "ID"},
+		{"Type", "Type"},
+		{"Records", "Records"},
+		{"Created", "Created"},
+		{"Status", "Status"},
+		{"Updated", "Updated"},
+		{"TTL", "TTL"},
 	})
-
-	return nil
 }
 
 func recordSetCreate(c *cli.Context) error {
@@ -222,82 +157,81 @@ func recordSetCreate(c *cli.Context) error {
 	}
 	t := typeSwitch(rtype)
 	if len(t) == 0 {
-		return fmt.Errorf("unknown --record-set-type %s", rtype)
+		return usageError(fmt.Errorf("unknown --record-set-type %s", rtype))
 	}
 
-	rdataS, err := getOption(c, "record-set-data")
+	records, err := parseRecords(t, c.StringSlice("record-set-data"))
 	if err != nil {
 		return err
 	}
 
-	rdata := strings.Split(rdataS, ",")
+	rs := vinyldns.RecordSet{
+		ZoneID: zoneID,
+		Name:   c.String("record-set-name"),
+		Type:   t,
+		TTL:    c.Int("record-set-ttl"),
+	}
 
-	var records []vinyldns.Record
+	rsc, err := submitRecordSet(client, rs, records)
+	if err != nil {
+		return err
+	}
 
-	if t == "CNAME" {
-		records = []vinyldns.Record{
-			{
-				CName: rdata[0],
-			},
-		}
-	} else if t == "MX" {
-		i, err := strconv.Atoi(rdata[0])
+	return printRecordSetChange(c, client, rsc, zoneID, rsc.RecordSet.ID, rsc.ChangeID, fmt.Sprintf("Created record set %s", name))
+}
+
+func recordSetUpdate(c *cli.Context) error {
+	if !c.IsSet("record-set-ttl") && !c.IsSet("record-set-data") && !c.IsSet("owner-group-id") && !c.IsSet("owner-group-name") {
+		return usageError(errors.New("nothing to update; pass at least one of '--record-set-ttl', '--record-set-data', '--owner-group-id', '--owner-group-name'"))
+	}
+
+	client := client(c)
+	zoneID, err := getZoneID(client, c.String("zone-id"), c.String("zone-name"))
+	if err != nil {
+		return err
+	}
+
+	rs, err := getRecordSet(client, zoneID, c.String("record-set-id"), c.String("record-set-name"), c.String("record-set-type"))
+	if err != nil {
+		return err
+	}
 
+	if c.IsSet("record-set-ttl") {
+		ttl, err := strconv.Atoi(c.String("record-set-ttl"))
 		if err != nil {
-			return err
+			return usageError(fmt.Errorf("invalid --record-set-ttl %s", c.String("record-set-ttl")))
 		}
+		rs.TTL = ttl
+	}
 
-		records = []vinyldns.Record{
-			{
-				Preference: i,
-				Exchange:   rdata[1],
-			},
-		}
-	} else if t == "PTR" {
-		records = []vinyldns.Record{
-			{
-				PTRDName: rdata[0],
-			},
-		}
-	} else if t == "TXT" {
-		records = []vinyldns.Record{
-			{
-				Text: rdataS,
-			},
-		}
-	} else {
-		records = []vinyldns.Record{
-			{
-				Address: rdata[0],
-			},
+	records := rs.Records
+	if c.IsSet("record-set-data") {
+		records, err = parseRecords(rs.Type, c.StringSlice("record-set-data"))
+		if err != nil {
+			return err
 		}
 	}
 
-	rs := &vinyldns.RecordSet{
-		ZoneID:  zoneID,
-		Name:    c.String("record-set-name"),
-		Type:    t,
-		TTL:     c.Int("record-set-ttl"),
-		Records: records,
+	if c.IsSet("owner-group-id") || c.IsSet("owner-group-name") {
+		id, err := getGroupID(client, c.String("owner-group-id"), c.String("owner-group-name"))
+		if err != nil {
+			return err
+		}
+		rs.OwnerGroupID = id
 	}
 
-	rsc, err := client.RecordSetCreate(rs)
+	rsc, err := submitRecordSet(client, rs.RecordSet, records)
 	if err != nil {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(rsc)
-	}
-
-	fmt.Printf("Created record set %s\n", name)
-	return nil
+	return printRecordSetChange(c, client, rsc, zoneID, rs.ID, rsc.ChangeID, fmt.Sprintf("Updated record set %s", rs.Name))
 }
 
 func recordSetDelete(c *cli.Context) error {
 	id := c.String("record-set-id")
 	if len(id) == 0 {
-		return errors.New("--record-set-id is required")
+		return usageError(errors.New("--record-set-id is required"))
 	}
 
 	client := client(c)
@@ -306,12 +240,7 @@ func recordSetDelete(c *cli.Context) error {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(d)
-	}
-
-	fmt.Printf("Deleted record set %s\n", id)
-	return nil
+	return printRecordSetChange(c, client, d, c.String("zone-id"), id, d.ChangeID, fmt.Sprintf("Deleted record set %s", id))
 }
 
 func getRecord(recs []vinyldns.Record) string {
@@ -12,7 +12,19 @@ limitations under the License.
 
 package main
 
-import "strconv"
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/urfave/cli"
+	"github.com/vinyldns/go-vinyldns/vinyldns"
+)
 
 func typeSwitch(t string) string {
 	switch t {
@@ -28,10 +40,488 @@ func typeSwitch(t string) string {
 		return "PTR"
 	case "TXT", "txt":
 		return "TXT"
+	case "NS", "ns":
+		return "NS"
+	case "SRV", "srv":
+		return "SRV"
+	case "SSHFP", "sshfp":
+		return "SSHFP"
+	case "NAPTR", "naptr":
+		return "NAPTR"
+	case "DS", "ds":
+		return "DS"
+	case "SPF", "spf":
+		return "SPF"
 	}
 	return ""
 }
 
+// record extends vinyldns.Record with the NAPTR and DS fields that
+// go-vinyldns does not model. FingerprintType shadows vinyldns.Record's Type,
+// which go-vinyldns declares as a string although the API sends a number.
+type record struct {
+	vinyldns.Record
+	FingerprintType int    `json:"type,omitempty"`
+	Order           int    `json:"order,omitempty"`
+	Flags           string `json:"flags,omitempty"`
+	Service         string `json:"service,omitempty"`
+	Regexp          string `json:"regexp,omitempty"`
+	Replacement     string `json:"replacement,omitempty"`
+	KeyTag          int    `json:"keytag,omitempty"`
+	DigestType      int    `json:"digesttype,omitempty"`
+	Digest          string `json:"digest,omitempty"`
+}
+
+// recordSetPayload is a vinyldns.RecordSet whose records may carry NAPTR and DS data.
+type recordSetPayload struct {
+	vinyldns.RecordSet
+	Records []record `json:"records"`
+}
+
+// recordSetUpdateResponse is a vinyldns.RecordSetUpdateResponse whose record
+// set may carry NAPTR, DS and SSHFP data.
+type recordSetUpdateResponse struct {
+	vinyldns.RecordSetUpdateResponse
+	RecordSet recordSetPayload `json:"recordSet"`
+}
+
+// extendedType reports whether records of type t need fields that
+// vinyldns.Record cannot carry, and must bypass go-vinyldns.
+func extendedType(t string) bool {
+	return t == "NAPTR" || t == "DS" || t == "SSHFP"
+}
+
+func toRecords(recs []vinyldns.Record) []record {
+	records := []record{}
+	for _, r := range recs {
+		records = append(records, record{Record: r})
+	}
+
+	return records
+}
+
+func fromRecords(recs []record) []vinyldns.Record {
+	records := []vinyldns.Record{}
+	for _, r := range recs {
+		records = append(records, r.Record)
+	}
+
+	return records
+}
+
+// listRecordSets lists all of a zone's record sets, bypassing go-vinyldns,
+// which cannot decode SSHFP records and drops the NAPTR and DS data.
+func listRecordSets(c *vinyldns.Client, zoneID string) ([]recordSetPayload, error) {
+	return pageRecordSets(c, zoneID, "")
+}
+
+// printRecordSetChange prints the response to a record set change or, with
+// --wait, the change once it has completed.
+func printRecordSetChange(c *cli.Context, client *vinyldns.Client, change interface{}, zoneID, recordSetID, changeID, message string) error {
+	if c.Bool("wait") {
+		done, err := waitForRecordSetChange(c, client, zoneID, recordSetID, changeID)
+		if err != nil {
+			return err
+		}
+		change = done
+	}
+
+	return printResult(c, change, message)
+}
+
+// submitRecordSet creates rs with records or, if rs already has an ID,
+// updates it.
+func submitRecordSet(c *vinyldns.Client, rs vinyldns.RecordSet, records []record) (*recordSetUpdateResponse, error) {
+	if !extendedType(rs.Type) {
+		rs.Records = fromRecords(records)
+		var resp *vinyldns.RecordSetUpdateResponse
+		var err error
+		if rs.ID == "" {
+			resp, err = c.RecordSetCreate(&rs)
+		} else {
+			resp, err = c.RecordSetUpdate(&rs)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &recordSetUpdateResponse{
+			RecordSetUpdateResponse: *resp,
+			RecordSet: recordSetPayload{
+				RecordSet: resp.RecordSet,
+				Records:   toRecords(resp.RecordSet.Records),
+			},
+		}, nil
+	}
+
+	method := http.MethodPost
+	path := fmt.Sprintf("/zones/%s/recordsets", rs.ZoneID)
+	if rs.ID != "" {
+		method = http.MethodPut
+		path = fmt.Sprintf("%s/%s", path, rs.ID)
+	}
+
+	resp := &recordSetUpdateResponse{}
+	err := request(c, method, path, recordSetPayload{RecordSet: rs, Records: records}, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// getRecordSet fetches a record set by ID or, without one, by name and
+// type. It bypasses go-vinyldns, which cannot decode SSHFP records.
+func getRecordSet(c *vinyldns.Client, zoneID, id, name, rtype string) (recordSetPayload, error) {
+	if id != "" {
+		resp := struct {
+			RecordSet recordSetPayload `json:"recordSet"`
+		}{}
+		err := request(c, http.MethodGet, fmt.Sprintf("/zones/%s/recordsets/%s", zoneID, id), nil, &resp)
+
+		return resp.RecordSet, err
+	}
+
+	return recordSetByName(c, zoneID, name, rtype)
+}
+
+func recordSetByName(c *vinyldns.Client, zoneID, name, rtype string) (recordSetPayload, error) {
+	var rs recordSetPayload
+	if rtype == "" {
+		return rs, usageError(fmt.Errorf("--record-set-type is required when looking up record set %s by name", name))
+	}
+	t := typeSwitch(rtype)
+	if len(t) == 0 {
+		return rs, usageError(fmt.Errorf("unknown --record-set-type %s", rtype))
+	}
+
+	sets, err := pageRecordSets(c, zoneID, name)
+	if err != nil {
+		return rs, err
+	}
+
+	for _, s := range sets {
+		if s.Name == name && s.Type == t {
+			return s, nil
+		}
+	}
+
+	return rs, notFoundError("Record set %s of type %s not found", name, t)
+}
+
+// pageRecordSets lists the record sets of a zone whose names match
+// nameFilter, or all of them if it is "", following nextId through the pages.
+func pageRecordSets(c *vinyldns.Client, zoneID, nameFilter string) ([]recordSetPayload, error) {
+	sets := []recordSetPayload{}
+	startFrom := ""
+	for {
+		query := url.Values{}
+		if nameFilter != "" {
+			query.Set("recordNameFilter", nameFilter)
+		}
+		if startFrom != "" {
+			query.Set("startFrom", startFrom)
+		}
+		path := fmt.Sprintf("/zones/%s/recordsets", zoneID)
+		if len(query) > 0 {
+			path += "?" + query.Encode()
+		}
+
+		page := struct {
+			RecordSets []recordSetPayload `json:"recordSets"`
+			NextID     string             `json:"nextId"`
+		}{}
+		if err := request(c, http.MethodGet, path, nil, &page); err != nil {
+			return nil, err
+		}
+		sets = append(sets, page.RecordSets...)
+
+		if page.NextID == "" {
+			return sets, nil
+		}
+		startFrom = page.NextID
+	}
+}
+
+// parseRecords builds one record per value in rdata. Values use zone file
+// rdata syntax, e.g. "10 5 5060 sip.ok." for SRV. Except for the free-form
+// TXT, SPF and NAPTR types, a value may itself be a comma-separated list, so
+// "1.1.1.1,2.2.2.2" and two separate --record-set-data flags produce the
+// same record set.
+func parseRecords(t string, rdata []string) ([]record, error) {
+	records := []record{}
+	for _, d := range rdata {
+		recs, err := parseRecordData(t, d)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, recs...)
+	}
+
+	if len(records) == 0 {
+		return nil, usageError(errors.New("--record-set-data is required"))
+	}
+	if t == "CNAME" && len(records) > 1 {
+		return nil, invalidError(fmt.Errorf("CNAME record sets can only contain one record; got %d", len(records)))
+	}
+
+	return records, nil
+}
+
+func parseRecordData(t, d string) ([]record, error) {
+	switch t {
+	case "TXT", "SPF":
+		return []record{{Record: vinyldns.Record{Text: d}}}, nil
+	case "NAPTR":
+		r, err := parseNAPTR(d)
+		if err != nil {
+			return nil, err
+		}
+		return []record{r}, nil
+	case "MX":
+		return parseMX(d)
+	}
+
+	records := []record{}
+	for _, v := range strings.Split(d, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		var r record
+		var err error
+		switch t {
+		case "CNAME":
+			r.CName = v
+		case "PTR":
+			r.PTRDName = v
+		case "NS":
+			r.NSDName, err = parseName("NS", v, v)
+		case "SRV":
+			r, err = parseSRV(v)
+		case "SSHFP":
+			r, err = parseSSHFP(v)
+		case "DS":
+			r, err = parseDS(v)
+		default:
+			r.Address = v
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, r)
+	}
+
+	return records, nil
+}
+
+// parseMX accepts one or more <preference>,<exchange> pairs, separated by
+// commas or spaces, e.g. "10,mx1.ok." or "10 mx1.ok.,20 mx2.ok.".
+func parseMX(d string) ([]record, error) {
+	fields := strings.FieldsFunc(d, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	if len(fields) == 0 || len(fields)%2 != 0 {
+		return nil, invalidError(fmt.Errorf("malformed MX data %q; expected <preference>,<exchange> pairs", d))
+	}
+
+	records := []record{}
+	for i := 0; i < len(fields); i += 2 {
+		pref, err := parseUint16("MX", d, "preference", fields[i])
+		if err != nil {
+			return nil, err
+		}
+		if _, err := strconv.Atoi(fields[i+1]); err == nil {
+			return nil, invalidError(fmt.Errorf("malformed MX data %q; exchange %q must be a host name", d, fields[i+1]))
+		}
+
+		records = append(records, record{Record: vinyldns.Record{
+			Preference: pref,
+			Exchange:   fields[i+1],
+		}})
+	}
+
+	return records, nil
+}
+
+// parseSRV parses "<priority> <weight> <port> <target>".
+func parseSRV(d string) (record, error) {
+	var r record
+	fields, err := rdataFields("SRV", d, 4, "<priority> <weight> <port> <target>")
+	if err != nil {
+		return r, err
+	}
+
+	if r.Priority, err = parseUint16("SRV", d, "priority", fields[0]); err != nil {
+		return r, err
+	}
+	if r.Weight, err = parseUint16("SRV", d, "weight", fields[1]); err != nil {
+		return r, err
+	}
+	if r.Port, err = parseUint16("SRV", d, "port", fields[2]); err != nil {
+		return r, err
+	}
+	r.Target, err = parseName("SRV", d, fields[3])
+
+	return r, err
+}
+
+// parseSSHFP parses "<algorithm> <type> <fingerprint>".
+func parseSSHFP(d string) (record, error) {
+	var r record
+	fields, err := rdataFields("SSHFP", d, 3, "<algorithm> <type> <fingerprint>")
+	if err != nil {
+		return r, err
+	}
+
+	// 1: RSA, 2: DSA, 3: ECDSA, 4: Ed25519, 6: Ed448
+	if r.Algorithm, err = parseBounded("SSHFP", d, "algorithm", fields[0], 1, 6); err != nil {
+		return r, err
+	}
+	if r.Algorithm == 5 {
+		return r, invalidError(fmt.Errorf("malformed SSHFP data %q; algorithm %q must be one of 1 (RSA), 2 (DSA), 3 (ECDSA), 4 (Ed25519) or 6 (Ed448)", d, fields[0]))
+	}
+
+	// 1: SHA-1, 2: SHA-256
+	if r.FingerprintType, err = parseBounded("SSHFP", d, "type", fields[1], 1, 2); err != nil {
+		return r, err
+	}
+
+	r.Fingerprint, err = parseDigest("SSHFP", d, "fingerprint", fields[2], map[int]int{1: 40, 2: 64}[r.FingerprintType])
+
+	return r, err
+}
+
+// parseNAPTR parses "<order> <preference> <flags> <service> <regexp> <replacement>".
+// flags, service and regexp may be quoted and may be empty ("").
+func parseNAPTR(d string) (record, error) {
+	var r record
+	fields, err := rdataFields("NAPTR", d, 6, "<order> <preference> <flags> <service> <regexp> <replacement>")
+	if err != nil {
+		return r, err
+	}
+
+	if r.Order, err = parseUint16("NAPTR", d, "order", fields[0]); err != nil {
+		return r, err
+	}
+	if r.Preference, err = parseUint16("NAPTR", d, "preference", fields[1]); err != nil {
+		return r, err
+	}
+	for _, f := range fields[2] {
+		if !unicode.IsLetter(f) && !unicode.IsDigit(f) {
+			return r, invalidError(fmt.Errorf("malformed NAPTR data %q; flags %q must be alphanumeric", d, fields[2]))
+		}
+	}
+	r.Flags = fields[2]
+	r.Service = fields[3]
+	r.Regexp = fields[4]
+	r.Replacement, err = parseName("NAPTR", d, fields[5])
+
+	return r, err
+}
+
+// parseDS parses "<key tag> <algorithm> <digest type> <digest>".
+func parseDS(d string) (record, error) {
+	var r record
+	fields, err := rdataFields("DS", d, 4, "<key tag> <algorithm> <digest type> <digest>")
+	if err != nil {
+		return r, err
+	}
+
+	if r.KeyTag, err = parseUint16("DS", d, "key tag", fields[0]); err != nil {
+		return r, err
+	}
+	if r.Algorithm, err = parseBounded("DS", d, "algorithm", fields[1], 1, 255); err != nil {
+		return r, err
+	}
+
+	// 1: SHA-1, 2: SHA-256, 4: SHA-384
+	digestLengths := map[int]int{1: 40, 2: 64, 4: 96}
+	if r.DigestType, err = parseBounded("DS", d, "digest type", fields[2], 1, 4); err != nil {
+		return r, err
+	}
+	if _, ok := digestLengths[r.DigestType]; !ok {
+		return r, invalidError(fmt.Errorf("malformed DS data %q; digest type %q must be one of 1 (SHA-1), 2 (SHA-256) or 4 (SHA-384)", d, fields[2]))
+	}
+
+	r.Digest, err = parseDigest("DS", d, "digest", fields[3], digestLengths[r.DigestType])
+
+	return r, err
+}
+
+// rdataFields splits d on whitespace, honoring double-quoted strings, and
+// checks that it has exactly n fields.
+func rdataFields(t, d string, n int, syntax string) ([]string, error) {
+	fields := []string{}
+	var field strings.Builder
+	inField, quoted, escaped := false, false, false
+
+	for _, r := range d {
+		switch {
+		case escaped:
+			field.WriteRune(r)
+			escaped = false
+		case r == '\\' && quoted:
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+			inField = true
+		case unicode.IsSpace(r) && !quoted:
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if quoted {
+		return nil, invalidError(fmt.Errorf("malformed %s data %q; unterminated quoted string", t, d))
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+
+	if len(fields) != n {
+		return nil, invalidError(fmt.Errorf("malformed %s data %q; expected %s", t, d, syntax))
+	}
+
+	return fields, nil
+}
+
+func parseUint16(t, d, name, v string) (int, error) {
+	return parseBounded(t, d, name, v, 0, 65535)
+}
+
+func parseBounded(t, d, name, v string, min, max int) (int, error) {
+	i, err := strconv.Atoi(v)
+	if err != nil || i < min || i > max {
+		return 0, invalidError(fmt.Errorf("malformed %s data %q; %s %q must be an integer between %d and %d", t, d, name, v, min, max))
+	}
+
+	return i, nil
+}
+
+func parseName(t, d, v string) (string, error) {
+	if v == "" || strings.ContainsFunc(v, unicode.IsSpace) {
+		return "", invalidError(fmt.Errorf("malformed %s data %q; %q is not a valid host name", t, d, v))
+	}
+
+	return v, nil
+}
+
+func parseDigest(t, d, name, v string, length int) (string, error) {
+	if _, err := hex.DecodeString(v); err != nil || len(v) != length {
+		return "", invalidError(fmt.Errorf("malformed %s data %q; %s must be %d hexadecimal characters", t, d, name, length))
+	}
+
+	return v, nil
+}
+
 func getRecordValue(records []string, recordValue interface{}, recordPrepend string) []string {
 	var strVal string
 	switch recordValue.(type) {
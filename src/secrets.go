@@ -0,0 +1,117 @@
+/*
+Copyright 2018 Comcast Cable Communications Management, LLC
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// secretNames are the struct fields and JSON or YAML keys whose values are
+// masked unless --show-secrets is passed: TSIG keys and VinylDNS secret keys.
+var secretNames = []string{"key", "secretkey", "secret-key", "secret_key"}
+
+// secretJSON matches secret values in JSON text, such as the request bodies
+// that API errors include.
+var secretJSON = regexp.MustCompile(`("(?i:key|secretKey|secret-key|secret_key)"\s*:\s*")((?:[^"\\]|\\.)*)(")`)
+
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretNames {
+		if name == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redact returns a copy of i with its secrets masked, or i itself if
+// --show-secrets is passed.
+func redact(c *cli.Context, i interface{}) interface{} {
+	if i == nil || c.GlobalBool(showSecretsFlag) {
+		return i
+	}
+
+	return redactValue(reflect.ValueOf(i)).Interface()
+}
+
+// redactText masks the secret values in JSON text.
+func redactText(s string) string {
+	return secretJSON.ReplaceAllStringFunc(s, func(m string) string {
+		parts := secretJSON.FindStringSubmatch(m)
+		return parts[1] + maskSecret(parts[2]) + parts[3]
+	})
+}
+
+func redactValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(redactValue(v.Elem()))
+		return p
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		i := reflect.New(v.Type()).Elem()
+		i.Set(redactValue(v.Elem()))
+		return i
+	case reflect.Struct:
+		s := reflect.New(v.Type()).Elem()
+		s.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			f := s.Field(i)
+			if !f.CanSet() {
+				continue
+			}
+			if f.Kind() == reflect.String && isSecret(v.Type().Field(i).Name) {
+				f.SetString(maskSecret(f.String()))
+				continue
+			}
+			f.Set(redactValue(v.Field(i)))
+		}
+		return s
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		s := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s.Index(i).Set(redactValue(v.Index(i)))
+		}
+		return s
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, k := range v.MapKeys() {
+			val := v.MapIndex(k)
+			if s, ok := val.Interface().(string); ok && k.Kind() == reflect.String && isSecret(k.String()) {
+				m.SetMapIndex(k, reflect.ValueOf(maskSecret(s)).Convert(val.Type()))
+				continue
+			}
+			m.SetMapIndex(k, redactValue(val))
+		}
+		return m
+	}
+
+	return v
+}
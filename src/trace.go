@@ -0,0 +1,124 @@
+/*
+Copyright 2018 Comcast Cable Communications Management, LLC
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// signature matches the signature in an AWS SigV4 Authorization header.
+var signature = regexp.MustCompile(`(Signature=)[0-9a-fA-F]+`)
+
+// tracingTransport logs each request it sends and the response it gets to
+// out: the method, URL, headers and body of the request, and the status,
+// latency and body of the response. Signatures, session tokens and secret
+// keys are masked, even if --show-secrets is passed.
+type tracingTransport struct {
+	next http.RoundTripper
+	out  io.Writer
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--> %s %s\n", req.Method, req.URL)
+	writeHeaders(&b, req.Header)
+	writeBody(&b, body)
+	io.WriteString(t.out, b.String())
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	latency := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(t.out, "<-- %s %s failed after %s: %v\n\n", req.Method, req.URL, latency, err)
+		return nil, err
+	}
+
+	contents, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		fmt.Fprintf(t.out, "<-- %s %s (%s): cannot read the body: %v\n\n", resp.Status, req.URL, latency, err)
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(contents))
+
+	b.Reset()
+	fmt.Fprintf(&b, "<-- %s %s (%s)\n", resp.Status, req.URL, latency)
+	writeBody(&b, contents)
+	io.WriteString(t.out, b.String())
+
+	return resp, nil
+}
+
+// requestBody returns the body of req, leaving req able to send it.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		r, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+func writeHeaders(b *strings.Builder, h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, v := range h[name] {
+			switch strings.ToLower(name) {
+			case "authorization":
+				v = signature.ReplaceAllString(v, "${1}****")
+			case "x-amz-security-token":
+				v = maskSecret(v)
+			}
+			fmt.Fprintf(b, "%s: %s\n", name, v)
+		}
+	}
+}
+
+func writeBody(b *strings.Builder, body []byte) {
+	if len(body) > 0 {
+		b.WriteString(redactText(string(body)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+}
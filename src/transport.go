@@ -0,0 +1,314 @@
+/*
+Copyright 2018 Comcast Cable Communications Management, LLC
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/urfave/cli"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// the defaults of the HTTP client settings
+const (
+	defaultTimeout      = time.Minute
+	defaultRetries      = 3
+	defaultRetryBackoff = 500 * time.Millisecond
+)
+
+// transportSettings are the HTTP client settings of the current profile.
+type transportSettings struct {
+	timeout      time.Duration
+	retries      int
+	retryBackoff time.Duration
+	tls          *tls.Config
+	httpsProxy   string
+	noProxy      string
+	debug        bool
+}
+
+// newTransportSettings resolves the HTTP client settings from the global
+// flags, their environment variables and the current profile.
+func newTransportSettings(c *cli.Context, p profile) (transportSettings, error) {
+	get := func(flag string) string {
+		return setting(c, p, flag)
+	}
+
+	// --insecure-skip-verify=false overrides a profile that skips verification
+	insecure := p.InsecureSkipVerify
+	if c.GlobalIsSet(insecureSkipVerifyFlag) {
+		insecure = c.GlobalBool(insecureSkipVerifyFlag)
+	}
+
+	s, err := parseTransportSettings(get, insecure)
+	s.debug = c.GlobalBool(debugFlag)
+
+	return s, err
+}
+
+// parseTransportSettings parses the HTTP client settings that get returns
+// by flag name, defaulting the ones it returns as "", and loads the
+// certificates they name.
+func parseTransportSettings(get func(flag string) string, insecure bool) (transportSettings, error) {
+	s := transportSettings{
+		timeout:      defaultTimeout,
+		retries:      defaultRetries,
+		retryBackoff: defaultRetryBackoff,
+		httpsProxy:   get(httpsProxyFlag),
+		noProxy:      get(noProxyFlag),
+	}
+
+	if v := get(timeoutFlag); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return s, fmt.Errorf("invalid --%s %q; use a duration such as 30s or 2m, or 0 for none", timeoutFlag, v)
+		}
+		s.timeout = d
+	}
+	if v := get(retriesFlag); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return s, fmt.Errorf("invalid --%s %q; use a number of retries, or 0 for none", retriesFlag, v)
+		}
+		s.retries = n
+	}
+	if v := get(retryBackoffFlag); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return s, fmt.Errorf("invalid --%s %q; use a duration such as 500ms or 2s", retryBackoffFlag, v)
+		}
+		s.retryBackoff = d
+	}
+	if s.httpsProxy != "" {
+		if u, err := url.Parse(s.httpsProxy); err != nil || u.Host == "" {
+			return s, fmt.Errorf("invalid --%s %q; use a URL such as http://proxy.example.com:3128", httpsProxyFlag, s.httpsProxy)
+		}
+	}
+
+	t, err := tlsConfig(get(caCertFlag), get(clientCertFlag), get(clientKeyFlag), insecure)
+	if err != nil {
+		return s, err
+	}
+	s.tls = t
+
+	return s, nil
+}
+
+// tlsConfig trusts the CA certificates in the PEM file caCert as well as the
+// system ones, and presents the client certificate in certFile, with the
+// private key in keyFile, to servers that ask for one.
+func tlsConfig(caCert, certFile, keyFile string, insecure bool) (*tls.Config, error) {
+	t := &tls.Config{InsecureSkipVerify: insecure}
+
+	if caCert != "" {
+		pem, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read --%s: %w", caCertFlag, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("--%s %s has no PEM certificates", caCertFlag, caCert)
+		}
+		t.RootCAs = pool
+	}
+
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("--%s and --%s must be passed together", clientCertFlag, clientKeyFlag)
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load --%s and --%s: %w", clientCertFlag, clientKeyFlag, err)
+		}
+		t.Certificates = []tls.Certificate{cert}
+	}
+
+	return t, nil
+}
+
+// newHTTPTransport returns the default transport configured with s, tracing
+// each attempt of a request to stderr if s.debug is set. The proxy settings of
+// s override HTTPS_PROXY and NO_PROXY, and follow the usual rules for those
+// variables.
+func newHTTPTransport(s transportSettings) (http.RoundTripper, error) {
+	t, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("the default HTTP transport cannot be configured")
+	}
+	t = t.Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	t.TLSClientConfig = s.tls
+
+	if s.httpsProxy != "" || s.noProxy != "" {
+		proxy := httpproxy.FromEnvironment()
+		if s.httpsProxy != "" {
+			proxy.HTTPSProxy = s.httpsProxy
+		}
+		if s.noProxy != "" {
+			proxy.NoProxy = s.noProxy
+		}
+		proxyFunc := proxy.ProxyFunc()
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	if s.debug {
+		return newRetryTransport(&tracingTransport{next: t, out: os.Stderr}, s), nil
+	}
+
+	return newRetryTransport(t, s), nil
+}
+
+// retryTransport times out each attempt of a request after timeout, and
+// retries idempotent requests that fail with a network error or a 5xx
+// status, backing off exponentially with jitter between attempts. Other
+// requests, such as the POSTs that create and change things, are sent once:
+// a failure does not tell whether the API acted on them.
+type retryTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+}
+
+func newRetryTransport(next http.RoundTripper, s transportSettings) *retryTransport {
+	return &retryTransport{
+		next:    next,
+		timeout: s.timeout,
+		retries: s.retries,
+		backoff: s.retryBackoff,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !idempotent(req.Method) {
+		return t.attempt(req)
+	}
+
+	for n := 0; ; n++ {
+		resp, err := t.attempt(req)
+		if n >= t.retries || !retryable(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(t.delay(n)):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt sends req once, giving up after the timeout. The timeout covers
+// reading the response body too, so it is only released once the body is
+// closed.
+func (t *retryTransport) attempt(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("no response from %s within %s: %w", req.URL.Host, t.timeout, err)
+		}
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// delay is the jittered backoff before retry n + 1: a random duration
+// between half of and the full backoff * 2^n.
+func (t *retryTransport) delay(n int) time.Duration {
+	d := t.backoff << uint(n)
+	if d <= 0 {
+		return 0
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	return false
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	return resp.StatusCode >= 500
+}
+
+// rewind returns a copy of req that can be sent again, with a fresh body.
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return r, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("cannot retry %s %s: its body cannot be read again", req.Method, req.URL)
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = body
+
+	return r, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2018 Comcast Cable Communications Management, LLC
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+	"github.com/vinyldns/go-vinyldns/vinyldns"
+)
+
+// pollInterval is how long --wait sleeps between status checks.
+var pollInterval = 2 * time.Second
+
+// waitFlags are the flags of the commands that can wait for their change.
+var waitFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "wait",
+		Usage: "Wait until the change is complete, exiting non-zero if it fails",
+	},
+	cli.DurationFlag{
+		Name:  "timeout",
+		Value: 5 * time.Minute,
+		Usage: "How long to --wait before giving up, e.g. '90s' or '10m'",
+	},
+}
+
+// recordSetChangeStatus is a vinyldns.RecordSetChange with the system message
+// the API sets when the change fails.
+type recordSetChangeStatus struct {
+	vinyldns.RecordSetChange
+	RecordSet     recordSetPayload `json:"recordSet"`
+	SystemMessage string           `json:"systemMessage,omitempty"`
+}
+
+// zoneChangeStatus is a vinyldns.ZoneChange with its system message.
+type zoneChangeStatus struct {
+	vinyldns.ZoneChange
+	SystemMessage string `json:"systemMessage,omitempty"`
+}
+
+// recordChangeStatus is a vinyldns.RecordChange with its system message.
+type recordChangeStatus struct {
+	vinyldns.RecordChange
+	SystemMessage string `json:"systemMessage,omitempty"`
+}
+
+// batchChangeStatus is a vinyldns.BatchRecordChange whose changes carry
+// their system messages.
+type batchChangeStatus struct {
+	vinyldns.BatchRecordChange
+	Changes []recordChangeStatus `json:"changes,omitempty"`
+}
+
+// batchChange returns b as the go-vinyldns type, dropping system messages.
+func (b batchChangeStatus) batchChange() *vinyldns.BatchRecordChange {
+	bc := b.BatchRecordChange
+	bc.Changes = []vinyldns.RecordChange{}
+	for _, ch := range b.Changes {
+		bc.Changes = append(bc.Changes, ch.RecordChange)
+	}
+
+	return &bc
+}
+
+// poll calls check every pollInterval until it reports done or the --timeout
+// passes.
+func poll(c *cli.Context, what string, check func() (bool, error)) error {
+	timeout := c.Duration("timeout")
+	deadline := time.Now().Add(timeout)
+	for {
+		done, err := check()
+		if err != nil || done {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return timeoutError("Timed out after %s waiting for %s", timeout, what)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// waitForRecordSetChange polls a record set change until it is Complete or
+// Failed.
+func waitForRecordSetChange(c *cli.Context, client *vinyldns.Client, zoneID, recordSetID, changeID string) (*recordSetChangeStatus, error) {
+	rsc := &recordSetChangeStatus{}
+	path := fmt.Sprintf("/zones/%s/recordsets/%s/changes/%s", zoneID, recordSetID, changeID)
+	err := poll(c, fmt.Sprintf("record set change %s", changeID), func() (bool, error) {
+		if err := request(client, "GET", path, nil, rsc); err != nil {
+			return false, err
+		}
+		return rsc.Status == "Complete" || rsc.Status == "Failed", nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if rsc.Status == "Failed" {
+		return rsc, fmt.Errorf("Record set change %s failed: %s", changeID, rsc.SystemMessage)
+	}
+
+	return rsc, nil
+}
+
+// waitForZoneChange polls a zone's changes until the given change is Synced,
+// Complete or Failed. A zone that is still being created may not be found
+// yet, which counts as pending.
+func waitForZoneChange(c *cli.Context, client *vinyldns.Client, zoneID, changeID string) (*zoneChangeStatus, error) {
+	zc := &zoneChangeStatus{}
+	path := fmt.Sprintf("/zones/%s/changes", zoneID)
+	err := poll(c, fmt.Sprintf("zone change %s", changeID), func() (bool, error) {
+		changes := struct {
+			ZoneChanges []zoneChangeStatus `json:"zoneChanges"`
+		}{}
+		if err := request(client, "GET", path, nil, &changes); err != nil {
+			if e, ok := err.(*vinyldns.Error); ok && e.ResponseCode == 404 {
+				return false, nil
+			}
+			return false, err
+		}
+
+		for _, ch := range changes.ZoneChanges {
+			if ch.ID == changeID {
+				*zc = ch
+				switch ch.Status {
+				case "Synced", "Complete", "Failed":
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if zc.Status == "Failed" {
+		return zc, fmt.Errorf("Zone change %s failed: %s", changeID, zc.SystemMessage)
+	}
+
+	return zc, nil
+}
+
+// waitForBatchChange polls a batch change until it is processed, rejected or
+// cancelled. Batch changes pending review or scheduled for later are still
+// waited for.
+func waitForBatchChange(c *cli.Context, client *vinyldns.Client, id string) (*batchChangeStatus, error) {
+	bc := &batchChangeStatus{}
+	path := fmt.Sprintf("/zones/batchrecordchanges/%s", id)
+	err := poll(c, fmt.Sprintf("batch change %s", id), func() (bool, error) {
+		if err := request(client, "GET", path, nil, bc); err != nil {
+			return false, err
+		}
+		switch bc.Status {
+		case "Complete", "Failed", "PartialFailure", "Cancelled", "Rejected":
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch bc.Status {
+	case "Failed", "PartialFailure":
+		failures := []string{}
+		for _, ch := range bc.Changes {
+			if ch.Status == "Failed" {
+				failures = append(failures, fmt.Sprintf("%s %s %s: %s", ch.ChangeType, ch.InputName, ch.Type, ch.SystemMessage))
+			}
+		}
+		return bc, fmt.Errorf("Batch change %s finished with status %s:\n%s", id, bc.Status, strings.Join(failures, "\n"))
+	case "Rejected":
+		return bc, fmt.Errorf("Batch change %s was rejected: %s", id, bc.ReviewComment)
+	case "Cancelled":
+		return bc, fmt.Errorf("Batch change %s was cancelled", id)
+	}
+
+	return bc, nil
+}
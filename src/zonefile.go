@@ -0,0 +1,554 @@
+/*
+Copyright 2018 Comcast Cable Communications Management, LLC
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/vinyldns/go-vinyldns/vinyldns"
+)
+
+// writeZoneFile writes record sets as an RFC 1035 master file. Names are
+// written relative to origin and record TTLs are only written where they
+// differ from the zone file's $TTL.
+func writeZoneFile(w io.Writer, origin string, rsets []recordSetPayload) error {
+	origin = fqdn(origin)
+	sorted := append([]recordSetPayload{}, rsets...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return zoneFileLess(sorted[i], sorted[j], origin)
+	})
+
+	ttl := defaultTTL(sorted)
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(tw, "$ORIGIN %s\n", origin)
+	fmt.Fprintf(tw, "$TTL %d\n", ttl)
+
+	for _, rs := range sorted {
+		name := relativeName(rs.Name, origin)
+		recordTTL := ""
+		if rs.TTL != ttl {
+			recordTTL = strconv.Itoa(rs.TTL)
+		}
+
+		for _, r := range rs.Records {
+			rdata, ok := formatRecordData(rs.Type, r)
+			if !ok {
+				fmt.Fprintf(tw, "; skipped %s record set %s: unsupported type\n", rs.Type, name)
+				break
+			}
+			fmt.Fprintf(tw, "%s\t%s\tIN\t%s\t%s\n", name, recordTTL, rs.Type, rdata)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// zoneFileLess orders the SOA first, then the apex NS records, then the rest
+// by name and type.
+func zoneFileLess(a, b recordSetPayload, origin string) bool {
+	rank := func(rs recordSetPayload) int {
+		apex := relativeName(rs.Name, origin) == "@"
+		switch {
+		case rs.Type == "SOA":
+			return 0
+		case apex && rs.Type == "NS":
+			return 1
+		case apex:
+			return 2
+		}
+		return 3
+	}
+
+	if rank(a) != rank(b) {
+		return rank(a) < rank(b)
+	}
+	an, bn := strings.ToLower(relativeName(a.Name, origin)), strings.ToLower(relativeName(b.Name, origin))
+	if an != bn {
+		return an < bn
+	}
+
+	return a.Type < b.Type
+}
+
+// defaultTTL returns the most common TTL, preferring the lowest on a tie.
+func defaultTTL(rsets []recordSetPayload) int {
+	counts := map[int]int{}
+	for _, rs := range rsets {
+		counts[rs.TTL]++
+	}
+
+	ttl, most := 0, 0
+	for t, n := range counts {
+		if n > most || (n == most && t < ttl) {
+			ttl, most = t, n
+		}
+	}
+
+	return ttl
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+
+	return name + "."
+}
+
+// relativeName returns name relative to origin, "@" for the apex itself.
+// Record set names in VinylDNS are usually relative already.
+func relativeName(name, origin string) string {
+	n, o := strings.ToLower(name), strings.ToLower(origin)
+	switch {
+	case name == "" || name == "@" || n == o || fqdn(n) == o:
+		return "@"
+	case strings.HasSuffix(n, "."+o):
+		return name[:len(name)-len(o)-1]
+	}
+
+	return name
+}
+
+// formatRecordData returns r's rdata in zone file syntax, the same syntax
+// --record-set-data accepts.
+func formatRecordData(t string, r record) (string, bool) {
+	switch t {
+	case "A", "AAAA":
+		return r.Address, true
+	case "CNAME":
+		return r.CName, true
+	case "PTR":
+		return r.PTRDName, true
+	case "NS":
+		return r.NSDName, true
+	case "MX":
+		return fmt.Sprintf("%d %s", r.Preference, r.Exchange), true
+	case "TXT", "SPF":
+		return quoteText(r.Text), true
+	case "SRV":
+		return fmt.Sprintf("%d %d %d %s", r.Priority, r.Weight, r.Port, r.Target), true
+	case "SSHFP":
+		return fmt.Sprintf("%d %d %s", r.Algorithm, r.FingerprintType, r.Fingerprint), true
+	case "NAPTR":
+		return fmt.Sprintf("%d %d %s %s %s %s", r.Order, r.Preference, quoteString(r.Flags), quoteString(r.Service), quoteString(r.Regexp), r.Replacement), true
+	case "DS":
+		return fmt.Sprintf("%d %d %d %s", r.KeyTag, r.Algorithm, r.DigestType, r.Digest), true
+	case "SOA":
+		return fmt.Sprintf("%s %s %d %d %d %d %d", r.MName, r.RName, r.Serial, r.Refresh, r.Retry, r.Expire, r.Minimum), true
+	}
+
+	return "", false
+}
+
+// quoteText quotes TXT data, splitting it into the 255-byte character
+// strings a TXT record is made of.
+func quoteText(s string) string {
+	if s == "" {
+		return `""`
+	}
+
+	parts := []string{}
+	for len(s) > 255 {
+		parts = append(parts, quoteString(s[:255]))
+		s = s[255:]
+	}
+	parts = append(parts, quoteString(s))
+
+	return strings.Join(parts, " ")
+}
+
+// quoteString quotes s as an RFC 1035 character string, escaping quotes,
+// backslashes and non-printable bytes.
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// zoneToken is one word of a zone file; quoted words may contain spaces.
+type zoneToken struct {
+	text   string
+	quoted bool
+}
+
+// zoneFileRecord is one resource record read from a zone file, with its name
+// fully qualified.
+type zoneFileRecord struct {
+	Line   int
+	Origin string
+	Name   string
+	TTL    int
+	Type   string
+	Data   []zoneToken
+}
+
+// parseZoneFile reads an RFC 1035 master file. origin is used until the file
+// sets its own $ORIGIN; $INCLUDE is not supported.
+func parseZoneFile(r io.Reader, origin string) ([]zoneFileRecord, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := zoneFileEntries(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	origin = fqdn(origin)
+	records := []zoneFileRecord{}
+	owner, ttl, lastTTL := "", -1, -1
+	for _, e := range entries {
+		tokens := e.tokens
+		if !e.indented && !tokens[0].quoted && strings.HasPrefix(tokens[0].text, "$") {
+			switch directive := strings.ToUpper(tokens[0].text); {
+			case directive == "$ORIGIN" && len(tokens) == 2:
+				origin = qualifyName(tokens[1].text, origin)
+			case directive == "$TTL" && len(tokens) == 2:
+				if ttl, err = parseTTL(tokens[1].text); err != nil {
+					return nil, fmt.Errorf("line %d: %v", e.line, err)
+				}
+			case directive == "$INCLUDE":
+				return nil, fmt.Errorf("line %d: $INCLUDE is not supported", e.line)
+			default:
+				return nil, fmt.Errorf("line %d: malformed %s directive", e.line, tokens[0].text)
+			}
+			continue
+		}
+
+		if !e.indented {
+			owner = qualifyName(tokens[0].text, origin)
+			tokens = tokens[1:]
+		}
+		if owner == "" {
+			return nil, fmt.Errorf("line %d: record has no owner name", e.line)
+		}
+
+		rr := zoneFileRecord{Line: e.line, Origin: origin, Name: owner, TTL: -1}
+		for len(tokens) > 0 && rr.Type == "" {
+			word := tokens[0].text
+			tokens = tokens[1:]
+			if t, err := parseTTL(word); err == nil && rr.TTL < 0 {
+				rr.TTL = t
+				continue
+			}
+			switch strings.ToUpper(word) {
+			case "IN":
+				continue
+			case "CH", "CS", "HS":
+				return nil, fmt.Errorf("line %d: class %s is not supported", e.line, word)
+			}
+			rr.Type = strings.ToUpper(word)
+		}
+		if rr.Type == "" {
+			return nil, fmt.Errorf("line %d: record has no type", e.line)
+		}
+
+		switch {
+		case rr.TTL >= 0:
+			lastTTL = rr.TTL
+		case ttl >= 0:
+			rr.TTL = ttl
+		case lastTTL >= 0:
+			rr.TTL = lastTTL
+		default:
+			return nil, fmt.Errorf("line %d: record has no TTL and no $TTL is set", e.line)
+		}
+
+		rr.Data = tokens
+		records = append(records, rr)
+	}
+
+	return records, nil
+}
+
+type zoneFileEntry struct {
+	line     int
+	indented bool
+	tokens   []zoneToken
+}
+
+// zoneFileEntries splits a zone file into entries, joining lines continued
+// in parentheses and dropping comments.
+func zoneFileEntries(s string) ([]zoneFileEntry, error) {
+	entries := []zoneFileEntry{}
+	line, depth := 1, 0
+	entry := zoneFileEntry{line: 1, indented: strings.HasPrefix(s, " ") || strings.HasPrefix(s, "\t")}
+	var word strings.Builder
+	inWord, quoted := false, false
+
+	endWord := func() {
+		if inWord {
+			entry.tokens = append(entry.tokens, zoneToken{text: word.String(), quoted: quoted})
+			word.Reset()
+			inWord, quoted = false, false
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quoted && c == '"':
+			endWord()
+		case c == '\\' && i+1 < len(s):
+			inWord = true
+			if i+3 < len(s) && isDigit(s[i+1]) && isDigit(s[i+2]) && isDigit(s[i+3]) {
+				n, _ := strconv.Atoi(s[i+1 : i+4])
+				word.WriteByte(byte(n))
+				i += 3
+			} else {
+				i++
+				word.WriteByte(s[i])
+			}
+		case quoted:
+			if c == '\n' {
+				line++
+			}
+			word.WriteByte(c)
+		case c == '"':
+			endWord()
+			inWord, quoted = true, true
+		case c == ';':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '(':
+			endWord()
+			depth++
+		case c == ')':
+			endWord()
+			if depth == 0 {
+				return nil, fmt.Errorf("line %d: unbalanced ')'", line)
+			}
+			depth--
+		case c == '\n':
+			endWord()
+			line++
+			if depth == 0 {
+				if len(entry.tokens) > 0 {
+					entries = append(entries, entry)
+				}
+				entry = zoneFileEntry{line: line}
+				entry.indented = i+1 < len(s) && (s[i+1] == ' ' || s[i+1] == '\t')
+			}
+		case c == ' ' || c == '\t' || c == '\r':
+			endWord()
+		default:
+			inWord = true
+			word.WriteByte(c)
+		}
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("line %d: unterminated quoted string", entry.line)
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("line %d: unbalanced '('", entry.line)
+	}
+	endWord()
+	if len(entry.tokens) > 0 {
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// qualifyName makes a zone file name absolute: "@" is the origin and names
+// without a trailing dot are relative to it.
+func qualifyName(name, origin string) string {
+	switch {
+	case name == "@":
+		return origin
+	case strings.HasSuffix(name, "."):
+		return name
+	case origin == ".":
+		return name + "."
+	}
+
+	return name + "." + origin
+}
+
+// parseTTL parses a TTL in seconds or in BIND's units, e.g. "1h30m".
+func parseTTL(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return n, nil
+	}
+
+	units := map[byte]int{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}
+	total, n, digits := 0, 0, false
+	for i := 0; i < len(s); i++ {
+		c := s[i] | 0x20
+		switch {
+		case isDigit(s[i]):
+			n = n*10 + int(s[i]-'0')
+			digits = true
+		case units[c] > 0 && digits:
+			total += n * units[c]
+			n, digits = 0, false
+		default:
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+	}
+	if digits || s == "" {
+		return 0, fmt.Errorf("invalid TTL %q", s)
+	}
+
+	return total, nil
+}
+
+// rdataNames is the position of the domain name in the rdata of the types
+// that have one; zone files may write it relative to the origin.
+var rdataNames = map[string]int{"CNAME": 0, "PTR": 0, "NS": 0, "MX": 1, "SRV": 3, "NAPTR": 5}
+
+// zoneFileChanges turns zone file records into batch changes that add them.
+// Records a batch change cannot add are skipped with a warning.
+func zoneFileChanges(rrs []zoneFileRecord, zoneName string) ([]recordChange, error) {
+	apex := strings.ToLower(fqdn(zoneName))
+	changes := []recordChange{}
+	for _, rr := range rrs {
+		if strings.ToLower(rr.Name) == apex && (rr.Type == "SOA" || rr.Type == "NS") {
+			fmt.Fprintf(os.Stderr, "Warning: line %d: skipping the apex %s record; VinylDNS manages it\n", rr.Line, rr.Type)
+			continue
+		}
+		if !batchChangeTypes[rr.Type] {
+			fmt.Fprintf(os.Stderr, "Warning: line %d: skipping %s %s; batch changes do not support %s records\n", rr.Line, rr.Name, rr.Type, rr.Type)
+			continue
+		}
+
+		r, err := zoneFileRecordData(rr)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", rr.Line, err)
+		}
+
+		inputName := rr.Name
+		if rr.Type == "PTR" {
+			if ip, ok := reverseAddress(rr.Name); ok {
+				inputName = ip
+			}
+		}
+
+		changes = append(changes, recordChange{
+			RecordChange: vinyldns.RecordChange{
+				ChangeType: "Add",
+				InputName:  inputName,
+				Type:       rr.Type,
+				TTL:        rr.TTL,
+			},
+			Record: &r,
+		})
+	}
+
+	return changes, nil
+}
+
+// zoneFileRecordData parses a zone file record's rdata with the same parsers
+// as --record-set-data, once any relative name in it is made absolute.
+func zoneFileRecordData(rr zoneFileRecord) (record, error) {
+	txt := rr.Type == "TXT" || rr.Type == "SPF"
+	nameAt, hasName := rdataNames[rr.Type]
+	words := []string{}
+	for i, t := range rr.Data {
+		switch {
+		case txt:
+			words = append(words, t.text)
+		case t.quoted:
+			words = append(words, quoteString(t.text))
+		case hasName && i == nameAt:
+			words = append(words, qualifyName(t.text, rr.Origin))
+		default:
+			words = append(words, t.text)
+		}
+	}
+
+	// a TXT record's character strings make up one text value
+	sep := " "
+	if txt {
+		sep = ""
+	}
+
+	records, err := parseRecordData(rr.Type, strings.Join(words, sep))
+	if err != nil {
+		return record{}, err
+	}
+	if len(records) != 1 {
+		return record{}, fmt.Errorf("malformed %s data %q", rr.Type, strings.Join(words, sep))
+	}
+
+	return records[0], nil
+}
+
+// reverseAddress returns the IP address a reverse DNS name stands for, which
+// batch changes take as the input name of PTR records.
+func reverseAddress(name string) (string, bool) {
+	n := strings.TrimSuffix(strings.ToLower(name), ".")
+	var labels []string
+	var addr string
+	switch {
+	case strings.HasSuffix(n, ".in-addr.arpa"):
+		labels = strings.Split(strings.TrimSuffix(n, ".in-addr.arpa"), ".")
+		if len(labels) != 4 {
+			return "", false
+		}
+		for i := len(labels) - 1; i >= 0; i-- {
+			addr += labels[i]
+			if i > 0 {
+				addr += "."
+			}
+		}
+	case strings.HasSuffix(n, ".ip6.arpa"):
+		labels = strings.Split(strings.TrimSuffix(n, ".ip6.arpa"), ".")
+		if len(labels) != 32 {
+			return "", false
+		}
+		for i := len(labels) - 1; i >= 0; i-- {
+			addr += labels[i]
+			if i > 0 && i%4 == 0 {
+				addr += ":"
+			}
+		}
+	default:
+		return "", false
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return "", false
+	}
+
+	return ip.String(), true
+}
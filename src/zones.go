@@ -14,13 +14,26 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
 
-	"github.com/crackcomm/go-clitable"
 	"github.com/urfave/cli"
 	"github.com/vinyldns/go-vinyldns/vinyldns"
 )
 
+var zoneChangeFields = []field{
+	{"Zone", "Zone.Name"},
+	{"ZoneID", "Zone.ID"},
+	{"UserID", "UserID"},
+	{"ChangeType", "ChangeType"},
+	{"Status", "Status"},
+	{"Created", "Created"},
+	{"ID", "ID"},
+}
+
 func zones(c *cli.Context) error {
 	client := client(c)
 	zones, err := client.ZonesListAll(vinyldns.ListFilter{})
@@ -28,80 +41,154 @@ func zones(c *cli.Context) error {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(zones)
+	return printList(c, zones, fields("Name", "ID"),
+		fields("Name", "ID", "Email", "Status", "AdminGroupID", "Shared", "Created", "LatestSync"), "zones")
+}
+
+func zone(c *cli.Context) error {
+	client := client(c)
+	name := c.String("zone-name")
+	id := c.String("zone-id")
+	z, err := getZone(client, name, id)
+	if err != nil {
+		return err
 	}
 
-	data := [][]string{}
-	for _, z := range zones {
-		data = append(data, []string{
-			z.Name,
-			z.ID,
-		})
+	return printItem(c, z, fields("Name", "ID", "Status"))
+}
+
+func zoneACLList(c *cli.Context) error {
+	z, err := getZone(client(c), c.String("zone-name"), c.String("zone-id"))
+	if err != nil {
+		return err
 	}
 
-	if len(data) != 0 {
-		printTableWithHeaders([]string{"Name", "ID"}, data)
-	} else {
-		fmt.Printf("No zones found")
+	rules := []vinyldns.ACLRule{}
+	if z.ACL != nil {
+		rules = z.ACL.Rules
 	}
 
-	return nil
+	return printList(c, rules, aclRuleFields, nil, "ACL rules")
 }
 
-func zone(c *cli.Context) error {
+func zoneACLAdd(c *cli.Context) error {
 	client := client(c)
-	name := c.String("zone-name")
-	id := c.String("zone-id")
-	z, err := getZone(client, name, id)
+	z, err := getZone(client, c.String("zone-name"), c.String("zone-id"))
+	if err != nil {
+		return err
+	}
+	rule, err := aclRule(c, client)
 	if err != nil {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(z)
+	if z.ACL == nil {
+		z.ACL = &vinyldns.ZoneACL{Rules: []vinyldns.ACLRule{}}
+	}
+	for _, r := range z.ACL.Rules {
+		if sameACLRule(r, rule) && r.AccessLevel == rule.AccessLevel {
+			return printResult(c, z, fmt.Sprintf("No changes; zone %s already has the ACL rule", z.Name))
+		}
 	}
 
-	data := [][]string{
-		{"Name", z.Name},
-		{"ID", z.ID},
-		{"Status", z.Status},
+	// a rule for the same user or group, mask and types gets the new access
+	// level rather than a second, conflicting rule
+	rules := []vinyldns.ACLRule{}
+	old := ""
+	for _, r := range z.ACL.Rules {
+		if !sameACLRule(r, rule) {
+			rules = append(rules, r)
+			continue
+		}
+		if old == "" {
+			old = r.AccessLevel
+			if !c.IsSet("description") {
+				rule.Description = r.Description
+			}
+			rules = append(rules, rule)
+		}
+	}
+	if old == "" {
+		rules = append(rules, rule)
 	}
+	z.ACL.Rules = rules
 
-	printBasicTable(data)
+	if old != "" {
+		return updateZoneACL(c, client, z, fmt.Sprintf("Changed the access level of an ACL rule of zone %s from %s to %s", z.Name, old, rule.AccessLevel))
+	}
 
-	return nil
+	return updateZoneACL(c, client, z, fmt.Sprintf("Added an ACL rule to zone %s", z.Name))
 }
 
-func zoneDetails(c *cli.Context) error {
+// zoneACLRemove removes the ACL rules that match the flags. --access-level
+// only narrows the match when it is passed.
+func zoneACLRemove(c *cli.Context) error {
 	client := client(c)
-	id := c.String("zone-id")
-	z, err := getZoneDetails(client, id)
+	z, err := getZone(client, c.String("zone-name"), c.String("zone-id"))
 	if err != nil {
 		return err
 	}
+	rule, err := aclRule(c, client)
+	if err != nil {
+		return err
+	}
+
+	kept := []vinyldns.ACLRule{}
+	removed := 0
+	if z.ACL != nil {
+		for _, r := range z.ACL.Rules {
+			if sameACLRule(r, rule) && (rule.AccessLevel == "" || r.AccessLevel == rule.AccessLevel) {
+				removed++
+				continue
+			}
+			kept = append(kept, r)
+		}
+	}
+	if removed == 0 {
+		return notFoundError("No ACL rule of zone %s matches", z.Name)
+	}
+	z.ACL.Rules = kept
+
+	return updateZoneACL(c, client, z, fmt.Sprintf("Removed %d ACL rules from zone %s", removed, z.Name))
+}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(z)
+// updateZoneACL submits z with its changed ACL and prints the zone change or,
+// with --wait, the change once it has completed.
+func updateZoneACL(c *cli.Context, client *vinyldns.Client, z vinyldns.Zone, message string) error {
+	updated, err := client.ZoneUpdate(&z)
+	if err != nil {
+		return err
 	}
 
-	data := [][]string{
-		{"Name", z.Name},
-		{"Email", z.Email},
-		{"Status", z.Status},
-		{"AdminGroupID", z.AdminGroupID},
-		{"AdminGroupName", z.AdminGroupName},
+	var zc interface{} = updated
+	if c.Bool("wait") {
+		zc, err = waitForZoneChange(c, client, z.ID, updated.ID)
+		if err != nil {
+			return err
+		}
 	}
 
-	printBasicTable(data)
+	return printResult(c, zc, message)
+}
+
+func zoneDetails(c *cli.Context) error {
+	client := client(c)
+	id := c.String("zone-id")
+	z, err := getZoneDetails(client, id)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return printItem(c, z, fields("Name", "Email", "Status", "AdminGroupID", "AdminGroupName"))
 }
 
 func zoneUpdate(c *cli.Context) error {
-	data := []byte(c.String("json"))
+	if c.String("json") == "" && c.String("file") == "" {
+		return zoneUpdateFromFlags(c)
+	}
+
 	zone := &vinyldns.Zone{}
-	if err := json.Unmarshal(data, &zone); err != nil {
+	if err := readPayload(c, zone); err != nil {
 		return err
 	}
 	client := client(c)
@@ -109,11 +196,80 @@ func zoneUpdate(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(updated)
+	return printResult(c, updated, fmt.Sprintf("Updated zone %s", updated.Zone.Name))
+}
+
+// zoneUpdateFromFlags fetches the zone, changes only the fields whose flags
+// are passed, and shows what changes before submitting it.
+func zoneUpdateFromFlags(c *cli.Context) error {
+	client := client(c)
+	z, err := getZone(client, c.String("zone-name"), c.String("zone-id"))
+	if err != nil {
+		return err
+	}
+
+	updated := z
+	if c.IsSet("email") {
+		updated.Email = c.String("email")
+	}
+	if c.IsSet("admin-group-id") || c.IsSet("admin-group-name") {
+		if updated.AdminGroupID, err = getGroupID(client, c.String("admin-group-id"), c.String("admin-group-name")); err != nil {
+			return err
+		}
+	}
+	if c.IsSet("shared") {
+		updated.Shared = c.Bool("shared")
+	}
+	if c.IsSet("backend-id") {
+		updated.BackendID = c.String("backend-id")
+	}
+	if updated.Connection, err = mergeConnection(c, "zone", z.Connection); err != nil {
+		return err
+	}
+	if updated.TransferConnection, err = mergeConnection(c, "transfer", z.TransferConnection); err != nil {
+		return err
+	}
+
+	changes := zoneDiff(z, updated, c.GlobalBool(showSecretsFlag))
+	if len(changes) == 0 {
+		return printResult(c, z, fmt.Sprintf("No changes to zone %s", z.Name))
+	}
+
+	// as with apply, the diff is only shown in tables so that JSON and YAML
+	// output is just the result
+	if outputFormat(c) == tableOutput {
+		if err := printList(c, changes, fields("Field", "Old", "New"), nil, "changes"); err != nil {
+			return err
+		}
+	}
+	if !c.Bool("yes") {
+		ok, err := confirm(fmt.Sprintf("Update zone %s?", z.Name))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("Update cancelled")
+		}
+	}
+
+	// vinyldns.Zone omits shared when it is false, which would leave a
+	// shared zone shared
+	payload := map[string]interface{}{}
+	j, err := json.Marshal(updated)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(j, &payload); err != nil {
+		return err
+	}
+	payload["shared"] = updated.Shared
+
+	resp := &vinyldns.ZoneUpdateResponse{}
+	if err := request(client, http.MethodPut, "/zones/"+z.ID, payload, resp); err != nil {
+		return err
 	}
-	fmt.Printf("Updated zone %s\n", updated.Zone.Name)
-	return nil
+
+	return printResult(c, resp, fmt.Sprintf("Updated zone %s", z.Name))
 }
 
 func zoneDelete(c *cli.Context) error {
@@ -124,13 +280,7 @@ func zoneDelete(c *cli.Context) error {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(deleted)
-	}
-
-	fmt.Printf("Deleted zone %s\n", id)
-
-	return nil
+	return printResult(c, deleted, fmt.Sprintf("Deleted zone %s", id))
 }
 
 func zoneCreate(c *cli.Context) error {
@@ -158,7 +308,7 @@ func zoneCreate(c *cli.Context) error {
 		return err
 	}
 
-	id, err := getAdminGroupID(client, c.String("admin-group-id"), c.String("admin-group-name"))
+	id, err := getGroupID(client, c.String("admin-group-id"), c.String("admin-group-name"))
 	if err != nil {
 		return err
 	}
@@ -180,13 +330,15 @@ func zoneCreate(c *cli.Context) error {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(created)
+	var z interface{} = created
+	if c.Bool("wait") {
+		z, err = waitForZoneChange(c, client, created.Zone.ID, created.ID)
+		if err != nil {
+			return err
+		}
 	}
 
-	fmt.Printf("Created zone %s\n", created.Zone.Name)
-
-	return nil
+	return printResult(c, z, fmt.Sprintf("Created zone %s", created.Zone.Name))
 }
 
 func zoneConnection(c *cli.Context) error {
@@ -198,26 +350,11 @@ func zoneConnection(c *cli.Context) error {
 	}
 	con := z.Connection
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(con)
-	}
-
 	if con == nil {
-		fmt.Printf("No zone connection found for zone %s\n", id)
-
-		return nil
-	}
-
-	data := [][]string{
-		{"Name", con.Name},
-		{"KeyName", con.KeyName},
-		{"Key", con.Key},
-		{"PrimaryServer", con.PrimaryServer},
+		return printResult(c, con, fmt.Sprintf("No zone connection found for zone %s", id))
 	}
 
-	printBasicTable(data)
-
-	return nil
+	return printItem(c, con, fields("Name", "KeyName", "Key", "PrimaryServer"))
 }
 
 func zoneChanges(c *cli.Context) error {
@@ -228,47 +365,126 @@ func zoneChanges(c *cli.Context) error {
 	}
 	cs := zh.ZoneChanges
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(cs)
+	return printList(c, cs, zoneChangeFields, nil, "zone changes")
+}
+
+func zoneSync(c *cli.Context) error {
+	client := client(c)
+	id, err := getZoneID(client, c.String("zone-id"), c.String("zone-name"))
+	if err != nil {
+		return err
+	}
+
+	zc, err := client.ZoneSync(id)
+	if err != nil {
+		return err
 	}
 
-	for _, c := range cs {
-		clitable.PrintHorizontal(map[string]interface{}{
-			"Zone":       c.Zone.Name,
-			"ZoneID":     c.Zone.ID,
-			"UserID":     c.UserID,
-			"ChangeType": c.ChangeType,
-			"Status":     c.Status,
-			"Created":    c.Created,
-			"ID":         c.ID,
-		})
+	var z interface{} = zc
+	if c.Bool("wait") {
+		z, err = waitForZoneChange(c, client, id, zc.ID)
+		if err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return printItem(c, z, []field{
+		{"Zone", "Zone.Name"},
+		{"ZoneID", "Zone.ID"},
+		{"ZoneStatus", "Zone.Status"},
+		{"UserID", "UserID"},
+		{"ChangeType", "ChangeType"},
+		{"SyncStatus", "Status"},
+		{"Created", "Created"},
+		{"ID", "ID"},
+	})
 }
 
-func zoneSync(c *cli.Context) error {
+func zoneExport(c *cli.Context) error {
 	client := client(c)
-	id, err := getZoneID(client, c.String("zone-id"), c.String("zone-name"))
-	z, err := client.ZoneSync(id)
+	z, err := getZone(client, c.String("zone-name"), c.String("zone-id"))
+	if err != nil {
+		return err
+	}
+
+	payloads, err := listRecordSets(client, z.ID)
 	if err != nil {
 		return err
 	}
 
-	if c.GlobalString(outputFlag) == "json" {
-		return printJSON(z)
+	path := c.String("file")
+	if path == "" || path == "-" {
+		return writeZoneFile(os.Stdout, z.Name, payloads)
 	}
 
-	clitable.PrintHorizontal(map[string]interface{}{
-		"Zone":       z.Zone.Name,
-		"ZoneID":     z.Zone.ID,
-		"ZoneStatus": z.Zone.Status,
-		"UserID":     z.UserID,
-		"ChangeType": z.ChangeType,
-		"SyncStatus": z.Status,
-		"Created":    z.Created,
-		"ID":         z.ID,
-	})
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeZoneFile(f, z.Name, payloads); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return printResult(c, map[string]interface{}{"zone": z.Name, "file": path, "recordSets": len(payloads)},
+		fmt.Sprintf("Exported %d record sets from zone %s to %s", len(payloads), z.Name, path))
+}
+
+func zoneImport(c *cli.Context) error {
+	zoneName := c.String("zone-name")
+	var in io.Reader = os.Stdin
+	if path := c.String("file"); path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	rrs, err := parseZoneFile(in, zoneName)
+	if err != nil {
+		return invalidError(err)
+	}
+
+	changes, err := zoneFileChanges(rrs, zoneName)
+	if err != nil {
+		return invalidError(err)
+	}
+	if len(changes) == 0 {
+		return invalidError(errors.New("the zone file has no records to import"))
+	}
+
+	bc := batchChangePayload{BatchRecordChange: vinyldns.BatchRecordChange{Comments: c.String("comments")}}
+	if c.Bool("dry-run") {
+		if ok, err := printStructured(c, chunkChanges(bc, changes, c.Int("chunk-size"))); ok {
+			return err
+		}
+		return printList(c, changes, fields("ChangeType", "InputName", "Type", "TTL", "Record"), nil, "changes")
+	}
 
-	return nil
+	client := client(c)
+	if c.IsSet("owner-group-id") || c.IsSet("owner-group-name") {
+		if bc.OwnerGroupID, err = getGroupID(client, c.String("owner-group-id"), c.String("owner-group-name")); err != nil {
+			return err
+		}
+	}
+
+	chunks := chunkChanges(bc, changes, c.Int("chunk-size"))
+	created := []*vinyldns.BatchRecordChangeUpdateResponse{}
+	for i, chunk := range chunks {
+		resp, err := submitBatchChange(client, chunk)
+		if err != nil {
+			if len(created) > 0 {
+				printList(c, created, fields("ID", "Status", "ApprovalStatus", "CreatedTimestamp"), nil, "batch changes")
+			}
+			return fmt.Errorf("batch change %d of %d failed: %w", i+1, len(chunks), err)
+		}
+		created = append(created, resp)
+	}
+
+	return printList(c, created, fields("ID", "Status", "ApprovalStatus", "CreatedTimestamp"), nil, "batch changes")
 }
@@ -13,11 +13,52 @@ limitations under the License.
 package main
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/urfave/cli"
 	"github.com/vinyldns/go-vinyldns/vinyldns"
 )
 
+// connectionFlags are the zone and transfer connection flags of zone-create
+// and zone-update.
+var connectionFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "transfer-connection-key-name",
+		Usage: "The zone transfer connection key name",
+	},
+	cli.StringFlag{
+		Name:  "transfer-connection-key",
+		Usage: "The zone transfer connection key",
+	},
+	cli.StringFlag{
+		Name:  "transfer-connection-primary-server",
+		Usage: "The zone transfer connection primary server",
+	},
+	cli.StringFlag{
+		Name:  "zone-connection-key-name",
+		Usage: "The zone connection key name",
+	},
+	cli.StringFlag{
+		Name:  "zone-connection-key",
+		Usage: "The zone connection key",
+	},
+	cli.StringFlag{
+		Name:  "zone-connection-primary-server",
+		Usage: "The zone zone connection primary server",
+	},
+}
+
+// zoneFieldChange is a zone field that zone-update changes.
+type zoneFieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
 func zoneByName(c *vinyldns.Client, name string) (vinyldns.Zone, error) {
 	var z vinyldns.Zone
 	zone, err := c.ZoneByName(name)
@@ -66,8 +107,190 @@ func validateConnection(connection string, c *vinyldns.ZoneConnection) (bool, er
 
 	// if any but not all are empty, we have a problem
 	if c.Key == "" || c.KeyName == "" || c.Name == "" || c.PrimaryServer == "" {
-		return false, fmt.Errorf("%s connection requires '--%s-connection-key-name', '--%s-connection-key', and '--%s-connection-primary-server'", connection, connection, connection, connection)
+		return false, usageError(fmt.Errorf("%s connection requires '--%s-connection-key-name', '--%s-connection-key', and '--%s-connection-primary-server'", connection, connection, connection, connection))
 	}
 
 	return true, nil
 }
+
+// mergeConnection applies the --<connection>-connection-* flags that are
+// passed to the current connection. Setting them all to "" removes it.
+func mergeConnection(c *cli.Context, connection string, current *vinyldns.ZoneConnection) (*vinyldns.ZoneConnection, error) {
+	merged := vinyldns.ZoneConnection{}
+	if current != nil {
+		merged = *current
+	}
+
+	set := false
+	for flag, value := range map[string]*string{
+		"key-name":       &merged.KeyName,
+		"key":            &merged.Key,
+		"primary-server": &merged.PrimaryServer,
+	} {
+		name := fmt.Sprintf("%s-connection-%s", connection, flag)
+		if c.IsSet(name) {
+			*value = c.String(name)
+			set = true
+		}
+	}
+	if !set {
+		return current, nil
+	}
+	if merged.Name == "" || c.IsSet(connection+"-connection-key-name") {
+		merged.Name = merged.KeyName
+	}
+
+	valid, err := validateConnection(connection, &merged)
+	if err != nil || !valid {
+		return nil, err
+	}
+
+	return &merged, nil
+}
+
+// zoneDiff lists the fields zone-update can change that differ between old
+// and updated. Connection keys are masked unless showSecrets is set.
+func zoneDiff(old, updated vinyldns.Zone, showSecrets bool) []zoneFieldChange {
+	changes := []zoneFieldChange{}
+	add := func(field, o, n string) {
+		if o != n {
+			changes = append(changes, zoneFieldChange{field, o, n})
+		}
+	}
+
+	add("email", old.Email, updated.Email)
+	add("adminGroupId", old.AdminGroupID, updated.AdminGroupID)
+	add("shared", strconv.FormatBool(old.Shared), strconv.FormatBool(updated.Shared))
+	add("backendId", old.BackendID, updated.BackendID)
+
+	for _, conn := range []struct {
+		field    string
+		old, new *vinyldns.ZoneConnection
+	}{
+		{"connection", old.Connection, updated.Connection},
+		{"transferConnection", old.TransferConnection, updated.TransferConnection},
+	} {
+		o, n := vinyldns.ZoneConnection{}, vinyldns.ZoneConnection{}
+		if conn.old != nil {
+			o = *conn.old
+		}
+		if conn.new != nil {
+			n = *conn.new
+		}
+		add(conn.field+".keyName", o.KeyName, n.KeyName)
+		add(conn.field+".primaryServer", o.PrimaryServer, n.PrimaryServer)
+		if o.Key != n.Key {
+			if !showSecrets {
+				o.Key, n.Key = maskSecret(o.Key), maskSecret(n.Key)
+			}
+			changes = append(changes, zoneFieldChange{conn.field + ".key", o.Key, n.Key})
+		}
+	}
+
+	return changes
+}
+
+var aclRuleFields = fields("AccessLevel", "GroupID", "UserID", "RecordMask", "RecordTypes", "Description")
+
+// aclAccessLevels are the access levels an ACL rule can grant.
+var aclAccessLevels = []string{"NoAccess", "Read", "Write", "Delete"}
+
+// aclRuleFlags are the flags zone-acl-add and zone-acl-remove match rules by.
+var aclRuleFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "zone-id",
+		Usage: "The zone ID",
+	},
+	cli.StringFlag{
+		Name:  "zone-name",
+		Usage: "The zone name (an alternative to --zone-id)",
+	},
+	cli.StringFlag{
+		Name:  "access-level",
+		Usage: "The access level the rule grants: " + strings.Join(aclAccessLevels, ", "),
+	},
+	cli.StringFlag{
+		Name:  "user",
+		Usage: "The user ID or username the rule applies to",
+	},
+	cli.StringFlag{
+		Name:  "group-id",
+		Usage: "The ID of the group the rule applies to",
+	},
+	cli.StringFlag{
+		Name:  "group-name",
+		Usage: "The name of the group the rule applies to (an alternative to group-id)",
+	},
+	cli.StringFlag{
+		Name:  "record-mask",
+		Usage: "The record names the rule applies to, as a regular expression (or a CIDR block in reverse zones)",
+	},
+	cli.StringSliceFlag{
+		Name:  "record-type",
+		Usage: "A record type the rule applies to; repeat the flag for multiple types, or leave it out for all types",
+	},
+}
+
+// aclDescriptionFlag is the flag of zone-acl-add that describes the rule.
+var aclDescriptionFlag = cli.StringFlag{
+	Name:  "description",
+	Usage: "The rule description",
+}
+
+// aclRule builds an ACL rule from the flags, resolving the user and group.
+func aclRule(c *cli.Context, client *vinyldns.Client) (vinyldns.ACLRule, error) {
+	rule := vinyldns.ACLRule{
+		RecordMask:  c.String("record-mask"),
+		RecordTypes: []string{},
+		Description: c.String("description"),
+	}
+
+	if level := c.String("access-level"); level != "" {
+		for _, l := range aclAccessLevels {
+			if strings.EqualFold(l, level) {
+				rule.AccessLevel = l
+			}
+		}
+		if rule.AccessLevel == "" {
+			return rule, usageError(fmt.Errorf("unknown --access-level %s; must be one of: %s", level, strings.Join(aclAccessLevels, ", ")))
+		}
+	} else if c.Command.Name == "zone-acl-add" {
+		return rule, usageError(errors.New("--access-level is required"))
+	}
+
+	if c.String("user") != "" && (c.String("group-id") != "" || c.String("group-name") != "") {
+		return rule, usageError(errors.New("an ACL rule applies to a user or a group, not both"))
+	}
+	if user := c.String("user"); user != "" {
+		u, err := getUser(client, user)
+		if err != nil {
+			return rule, err
+		}
+		rule.UserID = u.ID
+	}
+	if c.String("group-id") != "" || c.String("group-name") != "" {
+		id, err := getGroupID(client, c.String("group-id"), c.String("group-name"))
+		if err != nil {
+			return rule, err
+		}
+		rule.GroupID = id
+	}
+
+	for _, t := range c.StringSlice("record-type") {
+		rule.RecordTypes = append(rule.RecordTypes, strings.ToUpper(t))
+	}
+
+	return rule, nil
+}
+
+// sameACLRule reports whether two rules apply to the same user or group,
+// record mask and record types, whatever access they grant.
+func sameACLRule(a, b vinyldns.ACLRule) bool {
+	types := func(r vinyldns.ACLRule) string {
+		t := append([]string{}, r.RecordTypes...)
+		sort.Strings(t)
+		return strings.Join(t, ",")
+	}
+
+	return a.UserID == b.UserID && a.GroupID == b.GroupID && a.RecordMask == b.RecordMask && types(a) == types(b)
+}
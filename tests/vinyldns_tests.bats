@@ -1,5 +1,77 @@
 load test_helper
 
+@test "config show --output=json (masks the secret key)" {
+  config="${BATS_TMPDIR}/vinyldns-config.yaml"
+  rm -f "${config}"
+  bin/vinyldns --config "${config}" config set \
+    --name "dev" \
+    --host "http://localhost:9000" \
+    --access-key "okAccessKey" \
+    --secret-key "okSecretKey"
+
+  run bin/vinyldns --config "${config}" --output=json config show --name "dev"
+
+  fixture="$(cat tests/fixtures/config_show_json)"
+
+  [ "${output}" = "${fixture}" ]
+}
+
+@test "config set --timeout (with an invalid duration)" {
+  config="${BATS_TMPDIR}/vinyldns-config.yaml"
+  rm -f "${config}"
+
+  run bin/vinyldns --config "${config}" config set \
+    --name "dev" \
+    --timeout "soon"
+
+  [ "$status" -eq 2 ]
+  [ "${output}" = 'Error: invalid --timeout "soon"; use a duration such as 30s or 2m, or 0 for none' ]
+}
+
+@test "config set --client-cert (without --client-key)" {
+  config="${BATS_TMPDIR}/vinyldns-config.yaml"
+  rm -f "${config}"
+
+  run bin/vinyldns --config "${config}" config set \
+    --name "dev" \
+    --client-cert "tests/fixtures/client.pem"
+
+  [ "$status" -eq 2 ]
+  [ "${output}" = "Error: --client-cert and --client-key must be passed together" ]
+}
+
+@test "groups --ca-cert (with a file that has no certificates)" {
+  run $ew --ca-cert "tests/fixtures/plan.yaml" groups
+
+  [ "$status" -eq 2 ]
+  echo "${output}" | grep -- "--ca-cert tests/fixtures/plan.yaml has no PEM certificates"
+}
+
+@test "groups --retries (with an invalid number)" {
+  run $ew --retries "many" groups
+
+  [ "$status" -eq 2 ]
+  echo "${output}" | grep 'invalid --retries "many"'
+}
+
+@test "groups (without a host)" {
+  run bin/vinyldns --access-key=okAccessKey --secret-key=okSecretKey groups
+
+  [ "$status" -eq 2 ]
+  [ "${output}" = "Error: Please pass '--host', set 'VINYLDNS_HOST' or add it to a profile" ]
+}
+
+@test "group --output=json (when the group does not exist)" {
+  run $ew --output=json group --group-id "does-not-exist"
+
+  [ "$status" -eq 3 ]
+  echo "${output}" | grep '^{"code":"not_found","exitCode":3,"message":".*","httpStatus":404}$'
+}
+
+@test "group (writes errors to stderr only)" {
+  [ -z "$($ew group --group-id "does-not-exist" 2>/dev/null)" ]
+}
+
 @test "groups (when none exist)" {
   fixture="$(cat tests/fixtures/groups_none)"
   $ew groups | grep "${fixture}"
@@ -19,6 +91,35 @@ load test_helper
   [ "${output}" = "${fixture}" ]
 }
 
+@test "group-create --name (makes the creating user an admin)" {
+  name="flags-group-$$"
+  run $ew group-create --name "${name}" --email "test@test.com" --member "dummy"
+
+  [ "$status" -eq 0 ]
+  [ "${output}" = "Created group ${name}" ]
+  admins="$($ew --output='jsonpath={.admins[*].id}' group --name "${name}")"
+  [[ " ${admins} " == *" ok "* ]]
+  members="$($ew --output='jsonpath={.members[*].id}' group --name "${name}")"
+  [[ " ${members} " == *" ok "* ]]
+  [[ " ${members} " == *" dummy "* ]]
+
+  $ew group-delete --group-id "$($ew --output='jsonpath={.id}' group --name "${name}")"
+}
+
+@test "group-create --name (without --email)" {
+  run $ew group-create --name "flags-group"
+
+  [ "$status" -eq 2 ]
+  [ "${output}" = "Error: --email is required" ]
+}
+
+@test "group-create --name (when a member does not exist)" {
+  run $ew group-create --name "flags-group" --email "test@test.com" --member "no-such-user"
+
+  [ "$status" -eq 3 ]
+  echo "${output}" | grep "User no-such-user not found"
+}
+
 @test "groups (when groups exist)" {
   fixture="$(cat tests/fixtures/groups)"
   $ew groups | grep "${fixture}"
@@ -42,6 +143,65 @@ load test_helper
   $ew --output=json group --name "ok-group" | grep "${fixture}"
 }
 
+@test "group-member-add (when the user is already a member)" {
+  run $ew group-member-add --group-name "ok-group" --user "ok"
+
+  [ "$status" -eq 0 ]
+  [ "${output}" = "No changes; the users are already members of group ok-group" ]
+}
+
+@test "group-admin-add and group-admin-remove (keeps the user a member)" {
+  name="admins-group-$$"
+  id="$($ew --output='jsonpath={.id}' group-create --name "${name}" --email "test@test.com")"
+
+  run $ew group-admin-add --group-name "${name}" --user "dummy"
+
+  [ "$status" -eq 0 ]
+  [ "${output}" = "Added dummy to the admins of group ${name}" ]
+  $ew --output='jsonpath={.admins[*].id}' group --name "${name}" | grep -w "dummy"
+  $ew --output='jsonpath={.members[*].id}' group --name "${name}" | grep -w "dummy"
+
+  run $ew group-admin-remove --group-name "${name}" --user "dummy"
+
+  [ "$status" -eq 0 ]
+  [ "${output}" = "Removed dummy from the admins of group ${name}" ]
+  admins="$($ew --output='jsonpath={.admins[*].id}' group --name "${name}")"
+  [[ " ${admins} " != *" dummy "* ]]
+  $ew --output='jsonpath={.members[*].id}' group --name "${name}" | grep -w "dummy"
+
+  $ew group-delete --group-id "${id}"
+}
+
+@test "group-member-add and group-member-remove (also removes the user as an admin)" {
+  name="members-group-$$"
+  id="$($ew --output='jsonpath={.id}' group-create --name "${name}" --email "test@test.com")"
+
+  run $ew group-member-add --group-name "${name}" --user "dummy"
+
+  [ "$status" -eq 0 ]
+  [ "${output}" = "Added dummy to the members of group ${name}" ]
+  $ew --output='jsonpath={.members[*].id}' group --name "${name}" | grep -w "dummy"
+
+  $ew group-admin-add --group-name "${name}" --user "dummy"
+  run $ew group-member-remove --group-name "${name}" --user "dummy"
+
+  [ "$status" -eq 0 ]
+  [ "${output}" = "Removed dummy from the members of group ${name}" ]
+  members="$($ew --output='jsonpath={.members[*].id}' group --name "${name}")"
+  [[ " ${members} " != *" dummy "* ]]
+  admins="$($ew --output='jsonpath={.admins[*].id}' group --name "${name}")"
+  [[ " ${admins} " != *" dummy "* ]]
+
+  $ew group-delete --group-id "${id}"
+}
+
+@test "group-admin-add (when the user does not exist)" {
+  run $ew group-admin-add --group-name "ok-group" --user "no-such-user"
+
+  [ "$status" -eq 3 ]
+  echo "${output}" | grep "User no-such-user not found"
+}
+
 @test "group-update (when the group exists)" {
   fixture="$(cat tests/fixtures/group_updated)"
   ok_group=$($ew --op json group --name "ok-group")
@@ -105,7 +265,7 @@ load test_helper
 
   fixture="$(cat tests/fixtures/zone_create_invalid_zone_connection)"
 
-  [ "${status}" -eq 1 ]
+  [ "${status}" -eq 2 ]
   [ "${output}" = "${fixture}" ]
 }
 
@@ -119,7 +279,7 @@ load test_helper
 
   fixture="$(cat tests/fixtures/zone_create_invalid_transfer_connection)"
 
-  [ "${status}" -eq 1 ]
+  [ "${status}" -eq 2 ]
   [ "${output}" = "${fixture}" ]
 }
 
@@ -129,6 +289,185 @@ load test_helper
   $ew zone --zone-name "ok." | grep "${fixture}"
 }
 
+@test "zone --output=yaml (when the zone exists)" {
+  fixture="$(cat tests/fixtures/zone_yaml)"
+
+  $ew --output=yaml zone --zone-name "ok." | grep "${fixture}"
+}
+
+@test "zone --output=csv (when the zone exists)" {
+  $ew --output=csv zone --zone-name "ok." | grep "^Name,ID,Status$"
+}
+
+@test "zone --output=template (when the zone exists)" {
+  run $ew --output='template={{.Name}} {{.Email}}' zone --zone-name "ok."
+
+  [ "${output}" = "ok. test@test.com" ]
+}
+
+@test "zone --output=template (with an unknown field)" {
+  run $ew --output='template={{.Nmae}}' zone --zone-name "ok."
+
+  [ "${status}" -eq 1 ]
+  echo "${output}" | grep "at <.Nmae>"
+}
+
+@test "zone --output=jsonpath (when the zone exists)" {
+  run $ew --output='jsonpath={.name} {.connection.primaryServer}' zone --zone-name "ok."
+
+  [ "${output}" = "ok. bind" ]
+}
+
+@test "zone-acl-add and zone-acl-remove" {
+  $ew zone-acl-add --zone-name "ok." --access-level Read --group-name "ok-group" --record-mask "acl-test.*" --record-type A --wait
+  $ew --output=csv zone-acl-list --zone-name "ok." | grep "^Read,.*,acl-test\.\*,A,$"
+
+  run $ew zone-acl-remove --zone-name "ok." --group-name "ok-group" --record-mask "acl-test.*" --record-type A --wait
+
+  [ "${output}" = "Removed 1 ACL rules from zone ok." ]
+}
+
+@test "zone-acl-remove (when no rule matches)" {
+  run $ew zone-acl-remove --zone-name "ok." --group-name "ok-group" --record-mask "no-such-rule.*"
+
+  [ "$status" -eq 3 ]
+  [ "${output}" = "Error: No ACL rule of zone ok. matches" ]
+}
+
+@test "zone-acl-add (when a rule grants a different access level)" {
+  $ew zone-acl-add --zone-name "ok." --access-level Read --group-name "ok-group" --record-mask "acl-level.*" --wait
+
+  run $ew zone-acl-add --zone-name "ok." --access-level Write --group-name "ok-group" --record-mask "acl-level.*" --wait
+
+  [ "$status" -eq 0 ]
+  [ "${output}" = "Changed the access level of an ACL rule of zone ok. from Read to Write" ]
+  rules="$($ew --output=csv zone-acl-list --zone-name "ok." | grep ",acl-level\.\*,")"
+  [ "${rules}" = "$(echo "${rules}" | grep "^Write,")" ]
+  [ "$(echo "${rules}" | wc -l)" -eq 1 ]
+
+  $ew zone-acl-remove --zone-name "ok." --group-name "ok-group" --record-mask "acl-level.*" --wait
+}
+
+@test "zone-acl-add (with a user and a group)" {
+  run $ew zone-acl-add --zone-name "ok." --access-level Read --user "ok" --group-name "ok-group"
+
+  [ "$status" -eq 2 ]
+  [ "${output}" = "Error: an ACL rule applies to a user or a group, not both" ]
+}
+
+@test "zone-acl-add (with an unknown access level)" {
+  run $ew zone-acl-add --zone-name "ok." --access-level Admin
+
+  [ "$status" -eq 2 ]
+  echo "${output}" | grep "unknown --access-level Admin"
+}
+
+@test "zone-update --email (when the email is unchanged)" {
+  run $ew zone-update --zone-name "ok." --email "test@test.com"
+
+  [ "${output}" = "No changes to zone ok." ]
+}
+
+@test "zone-update --email --yes" {
+  run $ew zone-update --zone-name "ok." --email "update@test.com" --yes
+
+  [ "$status" -eq 0 ]
+  echo "${output}" | grep "| email | test@test.com | update@test.com |"
+  [ "$(echo "${output}" | tail -n 1)" = "Updated zone ok." ]
+
+  run $ew --output='jsonpath={.zone.email}' zone-update --zone-name "ok." --email "test@test.com" --yes
+
+  [ "$status" -eq 0 ]
+  [ "${output}" = "test@test.com" ]
+}
+
+@test "zone-update --transfer-connection-key (without the other connection flags)" {
+  run $ew zone-update --zone-name "ok." --transfer-connection-key "key"
+
+  [ "$status" -eq 2 ]
+  echo "${output}" | grep "transfer connection requires"
+}
+
+@test "zone --output=json (masks the connection key)" {
+  $ew --output=json zone --zone-name "ok." | grep '"key":"\*\*\*\*'
+}
+
+@test "zone --output=json --show-secrets (shows the connection key)" {
+  run $ew --show-secrets --output=json zone --zone-name "ok."
+
+  [ "$status" -eq 0 ]
+  ! echo "${output}" | grep '"key":"\*\*\*\*'
+}
+
+@test "zone --debug (masks the signature and connection key)" {
+  run $ew --debug zone --zone-name "ok."
+
+  [ "$status" -eq 0 ]
+  echo "${output}" | grep -- "--> GET http://localhost:9000/zones/name/ok."
+  echo "${output}" | grep "Signature=\*\*\*\*"
+  echo "${output}" | grep '"key":"\*\*\*\*'
+  ! echo "${output}" | grep "Signature=[0-9a-f]"
+}
+
+@test "zone-export" {
+  run $ew zone-export --zone-name "ok."
+
+  [ "${status}" -eq 0 ]
+  [ "${lines[0]}" = '$ORIGIN ok.' ]
+  echo "${output}" | grep "IN SOA"
+}
+
+@test "zone-import --dry-run" {
+  run $ew --output=csv zone-import --zone-name "ok." --file tests/fixtures/zone_import.zone --dry-run
+
+  fixture="$(cat tests/fixtures/zone_import_dry_run)"
+
+  [ "${output}" = "${fixture}" ]
+}
+
+@test "plan" {
+  $ew --output=csv plan --file tests/fixtures/plan.yaml | grep "^Create,ok.,plan-test,A,ttl 300; 1.2.3.4$"
+}
+
+@test "plan (with an SSHFP record set in the zone)" {
+  $ew record-set-create \
+    --zone-name "ok." \
+    --record-set-name "plan-sshfp" \
+    --record-set-type "SSHFP" \
+    --record-set-ttl "123" \
+    --record-set-data "1 1 2bb183af5f22588179a53b0a98631fad1a292118" \
+    --wait
+
+  $ew --output=csv plan --file tests/fixtures/plan_sshfp.yaml | grep "^Update,ok.,plan-sshfp,SSHFP,ttl 123 -> 300$"
+}
+
+@test "apply (when the manifest is read from stdin without --yes)" {
+  run $ew apply --file - < tests/fixtures/plan.yaml
+
+  [ "${status}" -eq 2 ]
+  echo "${output}" | grep -- "--yes is required"
+}
+
+@test "zones --columns (with --no-headers)" {
+  $ew --output=csv --columns Name,Email --no-headers --sort-by Name zones | grep "^ok.,test@test.com$"
+}
+
+@test "zones --columns (with an unknown field)" {
+  run $ew --columns Name,Foo zones
+
+  [ "${status}" -eq 2 ]
+  echo "${output}" | grep "unknown --columns field Foo"
+}
+
+@test "zones --output=xml (unknown output format)" {
+  run $ew --output=xml zones
+
+  fixture="$(cat tests/fixtures/output_unknown)"
+
+  [ "${status}" -eq 2 ]
+  [ "${output}" = "${fixture}" ]
+}
+
 @test "update zone (when the zone exists)" {
   fixture="$(cat tests/fixtures/zone_updated)"
   ok_zone=$($ew --op json zone --zone-name "ok.")
@@ -165,6 +504,48 @@ load test_helper
   [ "${output}" = "${fixture}" ]
 }
 
+@test "record-set-create (with --wait)" {
+  run $ew --output=json record-set-create \
+    --zone-name "ok." \
+    --record-set-name "some-waited-for" \
+    --record-set-type "A" \
+    --record-set-ttl "123" \
+    --record-set-data "4.4.4.4" \
+    --wait \
+    --timeout 30s
+
+  [ "${status}" -eq 0 ]
+  echo "${output}" | grep '"status":"Complete"'
+}
+
+@test "record-set-create (A, multiple records)" {
+  run $ew record-set-create \
+    --zone-name "ok." \
+    --record-set-name "some-round-robin" \
+    --record-set-type "A" \
+    --record-set-ttl "123" \
+    --record-set-data "1.1.1.1,2.2.2.2" \
+    --record-set-data "3.3.3.3"
+
+  fixture="$(cat tests/fixtures/record_set_create_a_multiple)"
+
+  [ "${output}" = "${fixture}" ]
+}
+
+@test "record-set-create (MX, malformed pair)" {
+  run $ew record-set-create \
+    --zone-name "ok." \
+    --record-set-name "some-bad-mx" \
+    --record-set-type "MX" \
+    --record-set-ttl "123" \
+    --record-set-data "test.com,3"
+
+  fixture="$(cat tests/fixtures/record_set_create_mx_malformed)"
+
+  [ "${status}" -eq 5 ]
+  [ "${output}" = "${fixture}" ]
+}
+
 @test "record-set-create (TXT)" {
   run $ew record-set-create \
     --zone-name "ok." \
@@ -178,6 +559,73 @@ load test_helper
   [ "${output}" = "${fixture}" ]
 }
 
+@test "record-set-create (SRV)" {
+  run $ew record-set-create \
+    --zone-name "ok." \
+    --record-set-name "_sip._tcp" \
+    --record-set-type "SRV" \
+    --record-set-ttl "123" \
+    --record-set-data "10 5 5060 sip1.ok.,20 5 5060 sip2.ok."
+
+  fixture="$(cat tests/fixtures/record_set_create_srv)"
+
+  [ "${output}" = "${fixture}" ]
+}
+
+@test "record-set-create (DS, malformed digest)" {
+  run $ew record-set-create \
+    --zone-name "ok." \
+    --record-set-name "some-ds" \
+    --record-set-type "DS" \
+    --record-set-ttl "123" \
+    --record-set-data "60485 5 1 2BB183AF"
+
+  fixture="$(cat tests/fixtures/record_set_create_ds_malformed)"
+
+  [ "${status}" -eq 5 ]
+  [ "${output}" = "${fixture}" ]
+}
+
+@test "record-set-update (CNAME, by name)" {
+  run $ew record-set-update \
+    --zone-name "ok." \
+    --record-set-name "some-cname" \
+    --record-set-type "CNAME" \
+    --record-set-ttl "456"
+
+  fixture="$(cat tests/fixtures/record_set_update_cname)"
+
+  [ "${output}" = "${fixture}" ]
+}
+
+@test "record-set-update (SSHFP, by name)" {
+  $ew record-set-create \
+    --zone-name "ok." \
+    --record-set-name "some-sshfp" \
+    --record-set-type "SSHFP" \
+    --record-set-ttl "123" \
+    --record-set-data "1 1 2bb183af5f22588179a53b0a98631fad1a292118" \
+    --wait
+
+  run $ew record-set-update \
+    --zone-name "ok." \
+    --record-set-name "some-sshfp" \
+    --record-set-type "SSHFP" \
+    --record-set-ttl "456"
+
+  fixture="$(cat tests/fixtures/record_set_update_sshfp)"
+
+  [ "${status}" -eq 0 ]
+  [ "${output}" = "${fixture}" ]
+}
+
+@test "record-set-update (with nothing to update)" {
+  run $ew record-set-update --zone-name "ok." --record-set-name "some-cname" --record-set-type "CNAME"
+
+  [ "$status" -eq 2 ]
+  echo "${output}" | grep "nothing to update"
+}
+
 @test "search-record-sets (when the search returns results)" {
   fixture="$(cat tests/fixtures/search_with_results)"
   $ew search-record-sets \
@@ -213,4 +661,92 @@ load test_helper
     --json "$(cat tests/fixtures/batch_change_create_json)"
 
   [ "$status" -eq 0 ]
+}
+
+@test "batch-change-create --file (with YAML)" {
+  run $ew batch-change-create --file tests/fixtures/batch_change_create_yaml
+
+  [ "$status" -eq 0 ]
+}
+
+@test "batch-change-create --json (from stdin)" {
+  run $ew batch-change-create --json - < tests/fixtures/batch_change_create_json
+
+  [ "$status" -eq 0 ]
+}
+
+@test "batch-changes --approval-status" {
+  run $ew --output=csv batch-changes --approval-status AutoApproved
+
+  [ "$status" -eq 0 ]
+  [ "${lines[0]}" = "ID,CreatedTimestamp,Comments" ]
+}
+
+@test "batch-change-reject (when the batch change does not exist)" {
+  run $ew batch-change-reject --batch-change-id does-not-exist --review-comment "no"
+
+  [ "$status" -eq 3 ]
+}
+
+@test "batch-change-create --csv" {
+  run $ew batch-change-create --csv tests/fixtures/batch_change_create.csv
+
+  [ "$status" -eq 0 ]
+}
+
+@test "batch-change-create --csv (with invalid lines)" {
+  run $ew batch-change-create --csv tests/fixtures/batch_change_create_invalid.csv
+
+  [ "$status" -eq 5 ]
+  echo "${output}" | grep 'line 2: TTL "soon" must be a positive number'
+  echo "${output}" | grep 'line 3: change type "Replace" must be Add or DeleteRecordSet'
+}
+
+@test "batch-change-create --scheduled-time (when the time is in the past)" {
+  run $ew batch-change-create --csv tests/fixtures/batch_change_create.csv --scheduled-time 2020-01-01T00:00:00Z
+
+  [ "$status" -eq 5 ]
+  echo "${output}" | grep "is in the past"
+}
+
+@test "batch-changes --scheduled" {
+  run $ew --output=csv batch-changes --scheduled
+
+  [ "$status" -eq 0 ]
+  [ "${lines[0]}" = "ScheduledTime,ID,Status,ApprovalStatus,UserName,Comments" ]
+}
+
+@test "api GET" {
+  run $ew --output=json api GET /zones
+
+  [ "$status" -eq 0 ]
+  echo "${output}" | grep '"zones":'
+}
+
+@test "api POST --data" {
+  name="api-group-$$"
+  run $ew --output='jsonpath={.id}' api POST /groups \
+    --data "{\"name\":\"${name}\",\"email\":\"test@test.com\",\"members\":[{\"id\":\"ok\"}],\"admins\":[{\"id\":\"ok\"}]}"
+
+  [ "$status" -eq 0 ]
+  id="${output}"
+
+  run $ew --output='jsonpath={.name}' api DELETE "/groups/${id}"
+
+  [ "$status" -eq 0 ]
+  [ "${output}" = "${name}" ]
+}
+
+@test "api --wide" {
+  run $ew --wide api GET /zones
+
+  [ "$status" -eq 2 ]
+  echo "${output}" | grep -- "--wide does not apply to api"
+}
+
+@test "api (with an unknown method)" {
+  run $ew api FETCH /zones
+
+  [ "$status" -eq 2 ]
+  [ "${output}" = "Error: unknown method FETCH; must be one of: GET, POST, PUT, PATCH, DELETE" ]
 }
\ No newline at end of file